@@ -26,12 +26,15 @@ var ParamTypeNames = map[string]ComponentParamType{
 
 // ComponentParam representation of a parameter of a component
 type ComponentParam struct {
-  Name      string
-  Type      ComponentParamType
-  TypeName  string
-  Required  bool
-  IsClient  bool            // Indicates that it is a parameter for client side (Javascript)
-  Reference *ComponentParam // When exposing the parameter to JS, it refers to a server parameter
+  Name         string
+  Type         ComponentParamType
+  TypeName     string
+  Required     bool
+  Default      string          // literal value used when the parameter is omitted (Ex. param-size="?number = 12")
+  HasDefault   bool
+  DefaultValue interface{}     // the Default evaluated and converted to the type (see sht.TemplateSystem.ParamDefault)
+  IsClient     bool            // Indicates that it is a parameter for client side (Javascript)
+  Reference    *ComponentParam // When exposing the parameter to JS, it refers to a server parameter
 }
 
 type ComponentConfig struct {
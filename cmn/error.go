@@ -40,3 +40,22 @@ func Err(code string, textAndDetails ...string) ErrFunc {
 		return fmt.Errorf(format, params...)
 	}
 }
+
+// ErrorList groups the errors found in a single pass, allowing to report all problems at once
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Err nil when the list is empty, the list itself otherwise
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -0,0 +1,171 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/cmn"
+	"github.com/syntax-framework/shtml/sht"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func Test_Compile_Cache(t *testing.T) {
+	files := fstest.MapFS{
+		"page.html":   {Data: []byte(`<link rel="include" href="header.html"/><my-card></my-card><script src="app.js"></script>`)},
+		"header.html": {Data: []byte(`<h1>Header</h1>`)},
+		"cards.html":  {Data: []byte(`<component name="my-card"><b>Card</b></component>`)},
+		"other.html":  {Data: []byte(`<p>Other</p>`)},
+		"app.js":      {Data: []byte(`console.log("A")`)},
+	}
+	directives := testGDs.NewChild()
+	directives.Add(Script)
+	ts := &sht.TemplateSystem{
+		Dev: true,
+		Loader: func(filepath string) (string, error) {
+			content, err := fs.ReadFile(files, filepath)
+			return string(content), err
+		},
+		Stat:       sht.StatFS(files),
+		Directives: directives,
+	}
+
+	compile := func(filepath string) *sht.Compiled {
+		compiled, _, err := ts.Compile(filepath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return compiled
+	}
+	render := func(compiled *sht.Compiled) string {
+		rendered, err := compiled.Execute(ts.NewScope())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rendered.String()
+	}
+	modTime := 0
+	modify := func(filepath string, content string) {
+		modTime++
+		files[filepath] = &fstest.MapFile{Data: []byte(content), ModTime: time.Unix(int64(modTime), 0)}
+	}
+
+	compile("cards.html")
+	page := compile("page.html")
+	other := compile("other.html")
+	if compile("page.html") != page || compile("other.html") != other {
+		t.Fatal("ts.Compile(filepath) | expect to return the cached template")
+	}
+
+	// include
+	modify("header.html", `<h1>Header B</h1>`)
+	if recompiled := compile("page.html"); recompiled == page || !strings.Contains(render(recompiled), "Header B") {
+		t.Errorf("ts.Compile(filepath) | expect to recompile the template when the include is modified\n   actual: %s", render(recompiled))
+	}
+	if compile("other.html") != other {
+		t.Errorf("ts.Compile(filepath) | expect to keep the templates that does not depend on the modified file")
+	}
+
+	// component declared in other file
+	modify("cards.html", `<component name="my-card"><b>Card B</b></component>`)
+	if actual := render(compile("page.html")); !strings.Contains(actual, "Card B") {
+		t.Errorf("ts.Compile(filepath) | expect to recompile the template when the component is modified\n   actual: %s", actual)
+	}
+
+	// script
+	var previous *cmn.Asset
+	for _, asset := range page.Assets {
+		if asset.Filepath == "app.js" {
+			previous = asset
+		}
+	}
+	modify("app.js", `console.log("B")`)
+	page = compile("page.html")
+	reloaded := false
+	for _, asset := range page.Assets {
+		reloaded = reloaded || (asset.Filepath == "app.js" && string(asset.Content) == `console.log("B")`)
+	}
+	if !reloaded {
+		t.Errorf("ts.Compile(filepath) | expect to reload the script")
+	}
+	if previous == nil || string(previous.Content) != `console.log("A")` {
+		t.Errorf("ts.Compile(filepath) | expect to keep the asset used by the previous compilation")
+	}
+
+	// not in dev mode, only by Invalidate
+	ts.Dev = false
+	modify("header.html", `<h1>Header C</h1>`)
+	if compile("page.html") != page {
+		t.Errorf("ts.Compile(filepath) | expect to return the cached template when not in dev mode")
+	}
+	ts.Invalidate("header.html")
+	if actual := render(compile("page.html")); !strings.Contains(actual, "Header C") {
+		t.Errorf("ts.Invalidate(filepath) | expect to recompile the template\n   actual: %s", actual)
+	}
+
+	// dev mode without Stat or FS
+	ts = &sht.TemplateSystem{Dev: true, Loader: ts.Loader, Directives: directives}
+	if _, _, err := ts.Compile("page.html"); err == nil || !strings.HasPrefix(err.Error(), "[compile.dev]") {
+		t.Errorf("ts.Compile(filepath) | invalid error\n expected: [compile.dev] .......\n   actual: %v", err)
+	}
+}
+
+func Test_CompileAll(t *testing.T) {
+	files := fstest.MapFS{
+		"components/card.html": {Data: []byte(`<component name="my-card" param-title="string"><b>{title | upper}</b></component>`)},
+		"pages/a.html":         {Data: []byte(`<my-card param-title="a"></my-card>`)},
+		"pages/b.html":         {Data: []byte(`<my-card param-title="b"></my-card><script src="app.js"></script>`)},
+		"c.html":               {Data: []byte(`<my-card param-title="c"></my-card><script src="pages/app.js"></script>`)},
+		"pages/app.js":         {Data: []byte(`console.log("app")`)},
+		"broken.html":          {Data: []byte(`<if cond="">A</if>`)},
+	}
+	directives := testGDs.NewChild()
+	directives.Add(Script)
+	ts := &sht.TemplateSystem{
+		FS: files,
+		Loader: func(filepath string) (string, error) {
+			content, err := fs.ReadFile(files, filepath)
+			return string(content), err
+		},
+		Directives: directives,
+	}
+
+	compiled, err := ts.CompileAll("components/*.html", "*.html")
+
+	errs, isList := err.(cmn.ErrorList)
+	if !isList || len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "[compile.file]") || !strings.Contains(errs[0].Error(), "broken.html") {
+		t.Errorf("ts.CompileAll(patterns) | expect to receive the error of broken.html\n   actual: %v", err)
+	}
+	if len(compiled) != 4 {
+		t.Fatalf("ts.CompileAll(patterns) | expect to compile 4 templates\n   actual: %d", len(compiled))
+	}
+	for file, expected := range map[string]string{"pages/a.html": "<b>A</b>", "pages/b.html": "<b>B</b>", "c.html": "<b>C</b>"} {
+		rendered, _ := compiled[file].Execute(ts.NewScope())
+		if !strings.Contains(rendered.String(), expected) {
+			t.Errorf("ts.CompileAll(patterns) | invalid output of %s\n   actual: %s\n expected: %s", file, rendered, expected)
+		}
+	}
+
+	// the concurrent compilations of the same file are collapsed into one
+	ts.Invalidate("pages/a.html")
+	results := make([]*sht.Compiled, 8)
+	wg := sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, _ = ts.Compile("pages/a.html")
+		}(i)
+	}
+	wg.Wait()
+	for _, result := range results {
+		if result == nil || result != results[0] || result == compiled["pages/a.html"] {
+			t.Fatalf("ts.Compile(filepath) | expect a single compilation of the concurrent calls")
+		}
+	}
+
+	if _, err = (&sht.TemplateSystem{}).CompileAll("*.html"); err == nil || !strings.HasPrefix(err.Error(), "[compile.fs]") {
+		t.Errorf("ts.CompileAll(patterns) | expect to receive [compile.fs] error\n   actual: %v", err)
+	}
+}
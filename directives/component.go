@@ -4,6 +4,8 @@ import (
 	"github.com/syntax-framework/shtml/cmn"
 	"github.com/syntax-framework/shtml/jsc"
 	"github.com/syntax-framework/shtml/sht"
+	"reflect"
+	"strings"
 )
 
 var errorCompNested = cmn.Err(
@@ -31,6 +33,11 @@ var errorCompScriptLocation = cmn.Err(
 	"Script element must be an immediate child of the component.", "Component: %s", "Script: %s",
 )
 
+var errorCompName = cmn.Err(
+	"component.name",
+	"The component element expects the name attribute.", "Component: %s",
+)
+
 // Component Responsible for creating components declaratively
 //
 // @TODO: Javascript directives?
@@ -44,6 +51,10 @@ var Component = &sht.Directive{
 
 		// @TODO: Parse include?
 
+		if strings.TrimSpace(attrs.Get("name")) == "" {
+			return nil, errorCompName(node.DebugTag())
+		}
+
 		var style *sht.Node
 		var script *sht.Node
 
@@ -107,21 +118,60 @@ var Component = &sht.Directive{
 		//	}
 		//}
 
-		inlineJs, inlineJsErr := jsc.Compile(node, script, t.Sequence)
+		// the params are removed from the node by jsc.Compile
+		params, paramsErr := jsc.ParseComponentParams(node)
+		if paramsErr != nil {
+			return nil, paramsErr
+		}
+
+		component := &sht.Component{
+			Name:   attrs.Get("name"),
+			File:   node.File,
+			Params: params.ServerParams,
+		}
+
+		if paramsErr = params.EvalDefaults(t.System, component.Name); paramsErr != nil {
+			return nil, paramsErr
+		}
+		inlineJs, inlineJsErr := jsc.CompileComponent(node, script, t.Sequence, params)
 		if inlineJsErr != nil {
 			return nil, inlineJsErr
 		}
 		if inlineJs != nil {
-			t.RegisterAssetJsContent(inlineJs.Content)
+			component.Assets = append(component.Assets, t.RegisterAssetJsContent(inlineJs.Content))
+			component.Events = inlineJs.Events
 		}
 
-		// @TODO: Registrar o componente no contexto de compilação
-		//t.RegisterComponent(&sht.Component{
-		//
-		//})
+		// the content of the component only has access to its params
+		t.IsolateEnv()
+		for _, param := range component.Params {
+			t.DeclareLocal(param.Name, componentParamType(param.Type))
+		}
+
+		// the content of the component is only rendered where it is used (<my-component></my-component>)
+		if component.Compiled, einlineJsErrr = t.CompileChildren(node.ExtractChildren()); einlineJsErrr != nil {
+			return
+		}
+
+		if einlineJsErrr = t.RegisterComponent(component); einlineJsErrr != nil {
+			return
+		}
 
 		// quando possui expr parametro live, expr componente não pode ter transclude
 		// Quando um script existir, todos os eventos DOM/Javascript serão substituidos por addEventListener
 		return
 	},
 }
+
+// componentParamType the Go type of the values of the param, nil when any value is accepted
+func componentParamType(paramType cmn.ComponentParamType) reflect.Type {
+	switch paramType {
+	case cmn.ParamTypeBool:
+		return reflect.TypeOf(false)
+	case cmn.ParamTypeString:
+		return reflect.TypeOf("")
+	case cmn.ParamTypeNumber:
+		return reflect.TypeOf(float64(0))
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package directives
 
 import (
 	"github.com/syntax-framework/shtml/sht"
+	"strings"
 	"testing"
 )
 
@@ -168,3 +169,191 @@ func Test_should_not_allow_side_effect_in_interpolation(t *testing.T) {
 
 	}
 }
+
+func Test_component_usage(t *testing.T) {
+	files := map[string]string{
+		"components.html": `
+    <component name="my-card" param-title="string" param-items="array" param-footer="?string"><h1>{title}</h1>
+      <ul><li for="item in items">{item}</li></ul>
+      <div><transclude/></div>
+      <footer><transclude slot="footer">{footer}</transclude></footer></component>`,
+		"template.html": `
+    <link rel="include" href="components.html"><my-card param-title="Title {value}" param-items="{items}">Body {value}<b slot="footer">Footer {value}</b></my-card>
+    <my-card param-title="Other" param-items="{[]}" param-footer="Default footer"/>`,
+	}
+
+	values := map[string]interface{}{
+		"value": "X",
+		"items": []string{"A", "B"},
+		"title": "outer title",
+	}
+
+	expected := `
+    <h1>Title X</h1>
+      <ul><li>A</li><li>B</li></ul>
+      <div>Body X</div>
+      <footer><b>Footer X</b></footer>
+    <h1>Other</h1>
+      <ul></ul>
+      <div></div>
+      <footer>Default footer</footer>`
+
+	testTemplateFiles(t, files, values, expected)
+}
+
+func Test_component_usage_unknown_param(t *testing.T) {
+	template := `
+    <component name="my-card" param-title="string">{title}</component>
+    <my-card param-title="A" param-other="B"></my-card>
+  `
+	testForErrorCode(t, template, "component.param.unknown")
+}
+
+func Test_component_should_have_name(t *testing.T) {
+	testForErrorCode(t, `<component param-title="string">{title}</component>`, "component.name")
+}
+
+func Test_component_should_not_allow_duplicate_name(t *testing.T) {
+	files := map[string]string{
+		"a.html": `<component name="my-card">A</component>`,
+		"b.html": `<component name="my-card">B</component>`,
+	}
+	template := `<link rel="include" href="a.html"><link rel="include" href="b.html">`
+	testForErrorCodeFiles(t, template, files, "component.duplicate")
+}
+
+func Test_component_params_coercion(t *testing.T) {
+	template := `
+    <component name="my-box" param-size="?number" param-flag="?bool" param-items="?array" param-title="?string">[{size + 0.5}|{flag ? 'yes' : 'no'}|{items}|{title}]</component><my-box param-size="12" param-flag="true" param-title="{size}"></my-box>
+    <my-box param-size="{size}" param-flag="{flag}" param-items="{items}"></my-box>
+    <my-box param-size="{title}" param-items="{title}"></my-box>`
+
+	values := map[string]interface{}{
+		"size":  int64(3),
+		"flag":  "false",
+		"items": []int{1, 2},
+		"title": "text",
+	}
+
+	expected := `
+    [12.5|yes||3]
+    [3.5|no|[1 2]|]
+    [|||]`
+
+	sht.TestTemplate(t, template, values, expected, testGDs)
+}
+
+func Test_component_params_coercion_strict(t *testing.T) {
+	ts := &sht.TemplateSystem{Directives: testGDs.NewChild(), Strict: true}
+	template := `<component name="my-box" param-size="?number">[{size}]</component><my-box param-size="{title}"></my-box>`
+
+	compiled, err := sht.NewCompiler(ts).Compile(template, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := ts.NewScope()
+	scope.Set("title", "text")
+	rendered, err := compiled.Execute(scope)
+	if actual, expected := rendered.String(), "[]"; actual != expected {
+		t.Errorf("compiled.Execute(scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "[component.param.type]") {
+		t.Errorf("compiled.Execute(scope) | invalid error\n expected: [component.param.type] .......\n   actual: %v", err)
+	}
+}
+
+func Test_component_params_validation(t *testing.T) {
+	testForErrorCode(t, `
+    <component name="my-box" param-size="number">{size}</component>
+    <my-box></my-box>
+  `, "component.param.required")
+
+	testForErrorCode(t, `
+    <component name="my-box" param-size="number">{size}</component>
+    <my-box param-size="large"></my-box>
+  `, "component.param.type")
+
+	testForErrorCode(t, `
+    <component name="my-box" param-flag="bool">{flag}</component>
+    <my-box param-flag="yes"></my-box>
+  `, "component.param.type")
+
+	testForErrorCode(t, `
+    <component name="my-box" param-items="array">{items}</component>
+    <my-box param-items="1, 2"></my-box>
+  `, "component.param.type")
+}
+
+func Test_component_params_default(t *testing.T) {
+	template := `
+    <component name="my-box" param-size="?number = 12" param-label="string = 'none'" param-items="?array = [1, 2]">[{size}|{label}|{items}]</component><my-box></my-box>
+    <my-box param-size="3" param-label="text" param-items="{[]}"></my-box>
+    <my-box param-size="{invalid}"></my-box>`
+
+	values := map[string]interface{}{
+		"invalid": "text",
+	}
+
+	expected := `
+    [12|none|[1 2]]
+    [3|text|[]]
+    [12|none|[1 2]]`
+
+	sht.TestTemplate(t, template, values, expected, testGDs)
+}
+
+func Test_component_params_default_validation(t *testing.T) {
+	testForErrorCode(t, `<component name="my-box" param-size="?number = ">{size}</component>`, "component.param.default")
+	testForErrorCode(t, `<component name="my-box" param-size="?number = 'large'">{size}</component>`, "component.param.default.type")
+	testForErrorCode(t, `<component name="my-box" param-size="?number = (">{size}</component>`, "component.param.default.type")
+}
+
+func Test_component_event_handlers(t *testing.T) {
+	ts := &sht.TemplateSystem{
+		Loader: testFileLoader(map[string]string{"template.html": `
+    <component name="my-counter"><button onclick="increment(count, 'step')">{count}</button>
+      <script>let count = 0;</script>
+    </component>`}),
+		Directives: testGDs.NewChild(),
+	}
+	if _, _, err := ts.Compile("template.html"); err != nil {
+		t.Fatal(err)
+	}
+	component := ts.Components["my-counter"]
+
+	if err := component.Handle("decrement", func(scope *sht.Scope) {}); err == nil || !strings.HasPrefix(err.Error(), "[component.event.unknown]") {
+		t.Errorf("component.Handle(event, handler) | invalid error\n expected: [component.event.unknown] .......\n   actual: %v", err)
+	}
+	if err := component.Handle("increment", func(count int) {}); err == nil || !strings.HasPrefix(err.Error(), "[component.event.handler]") {
+		t.Errorf("component.Handle(event, handler) | invalid error\n expected: [component.event.handler] .......\n   actual: %v", err)
+	}
+	if err := component.Handle("increment", func(scope *sht.Scope, count int) {}); err == nil || !strings.HasPrefix(err.Error(), "[component.event.args]") {
+		t.Errorf("component.Handle(event, handler) | invalid error\n expected: [component.event.args] .......\n   actual: %v", err)
+	}
+
+	err := component.Handle("increment", func(scope *sht.Scope, count int, label string) error {
+		scope.Set("count", count+1)
+		scope.Set("label", label)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := sht.NewRootScope()
+	// payload decoded from JSON
+	if err = component.Dispatch(scope, "increment", []interface{}{float64(2), "step"}); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := scope.Get("count"); count != 3 {
+		t.Errorf("component.Dispatch(scope, event, payload) | invalid count: %v", count)
+	}
+
+	if err = component.Dispatch(scope, "increment", []interface{}{float64(2)}); err == nil || !strings.HasPrefix(err.Error(), "[component.event.payload]") {
+		t.Errorf("component.Dispatch(scope, event, payload) | invalid error\n expected: [component.event.payload] .......\n   actual: %v", err)
+	}
+	if err = component.Dispatch(scope, "increment", []interface{}{"2", "step"}); err == nil || !strings.HasPrefix(err.Error(), "[component.event.payload]") {
+		t.Errorf("component.Dispatch(scope, event, payload) | invalid error\n expected: [component.event.payload] .......\n   actual: %v", err)
+	}
+}
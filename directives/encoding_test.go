@@ -0,0 +1,37 @@
+package directives
+
+import (
+	"testing"
+)
+
+func Test_Encode_Directives(t *testing.T) {
+	files := map[string]string{
+		"components.html": `
+    <component name="my-card" param-title="string" param-items="array" param-size="?number = 2"><h1>{title} {size}</h1>
+      <ul><li for="item in items" key="item">{item}</li></ul>
+      <div><transclude/></div>
+      <footer><transclude slot="footer">Default</transclude></footer></component>`,
+		"template.html": `
+    <link rel="include" href="components.html"><my-card param-title="Title {value}" param-items="{items}">Body<b slot="footer">Footer</b></my-card>
+    <if cond="value == 'X'">is X</if><else-if cond="value == 'Y'">is Y</else-if><else>other</else>
+    <span if="value == 'Y'">Y</span><span else>not Y</span>
+    <switch on="value"><case value="'Y'">case Y</case><case value="'X'">case X</case><default>default</default></switch>`,
+	}
+
+	values := map[string]interface{}{
+		"value": "X",
+		"items": []string{"A", "B"},
+	}
+
+	expected := `
+    <h1>Title X 2</h1>
+      <ul><li>A</li><li>B</li></ul>
+      <div>Body</div>
+      <footer><b>Footer</b></footer>
+    is X
+    <span>not Y</span>
+    case X`
+
+	testTemplateFiles(t, files, values, expected)
+	testEncodedTemplateFiles(t, files, values, expected)
+}
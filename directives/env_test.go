@@ -0,0 +1,84 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/cmn"
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+	"testing"
+)
+
+type testEnvUser struct {
+	Name  string
+	Admin bool
+}
+
+type testEnvPage struct {
+	Title string
+	User  *testEnvUser
+	Users []*testEnvUser
+	Tags  map[string]int
+}
+
+func testCompileWithEnv(template string) error {
+	ts := &sht.TemplateSystem{
+		Loader:     testFileLoader(map[string]string{"template.html": sht.TestUnindentedTemplate(template)}),
+		Directives: testGDs.NewChild(),
+	}
+	_, _, err := ts.CompileWithEnv("template.html", testEnvPage{})
+	return err
+}
+
+func Test_CompileWithEnv(t *testing.T) {
+	template := `
+    <component name="env-card" param-label="string">
+      <b>{label | upper}</b>
+    </component>
+    <h1 title="{Title}">{User.Name}</h1>
+    <if cond="User.Admin"><span>admin</span></if>
+    <ul>
+      <li for="user in Users" key="user.Name" class="{first ? 'first' : ''}">
+        <env-card param-label="{user.Name}"></env-card> {index}
+      </li>
+    </ul>
+    <for each="tag in Tags">{key}={tag + 1}</for>
+    <switch on="User.Name"><case value="'admin'">A</case></switch>`
+
+	if err := testCompileWithEnv(template); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CompileWithEnv_Errors(t *testing.T) {
+	template := `
+    <component name="env-card" param-label="string">
+      <b>{Title}</b>
+    </component>
+    <h1 title="{Title}">{User.Nmae}</h1>
+    <if cond="User.Admn"><span>admin</span></if>
+    <ul>
+      <li for="user in Users" class="{user.Nmae}">{index}</li>
+    </ul>
+    <span>{user.Name}</span>`
+
+	err := testCompileWithEnv(template)
+	errs, isList := err.(cmn.ErrorList)
+	if !isList {
+		t.Fatalf("ts.CompileWithEnv() | expect to receive cmn.ErrorList\n   actual: %v", err)
+	}
+
+	expected := []string{
+		"Expression: Title, File: template.html, Line: 2",
+		"Expression: User.Nmae, File: template.html, Line: 4",
+		"Expression: User.Admn, File: template.html, Line: 5",
+		"Expression: user.Nmae, File: template.html, Line: 7",
+		"Expression: user.Name, File: template.html, Line: 9",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("ts.CompileWithEnv() | invalid number of errors\n   actual: %v", err)
+	}
+	for i, e := range errs {
+		if !strings.HasPrefix(e.Error(), "[expression.env]") || !strings.Contains(e.Error(), expected[i]) {
+			t.Errorf("ts.CompileWithEnv() | invalid error\n   actual: %s\n expected: %s", e, expected[i])
+		}
+	}
+}
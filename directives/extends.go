@@ -0,0 +1,228 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/cmn"
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+)
+
+const keyExtendsParents = "extendsLayouts"
+
+var errorExtendsHref = cmn.Err(
+	"extends.href",
+	"The <extends> element expects the href attribute.", "Element: %s",
+)
+
+var errorExtendsContent = cmn.Err(
+	"extends.content",
+	"The <extends> element only accepts <block> elements.", "Content: %s", "Element: %s",
+)
+
+var errorExtendsCyclic = cmn.Err(
+	"extends.cyclic",
+	"Cyclic layout inheritance identified.", "File: %s", "Element: %s",
+)
+
+var errorExtendsLoad = cmn.Err(
+	"extends.load",
+	"Could not load the layout.", "File: %s", "Element: %s", "Cause: %s",
+)
+
+var errorBlockName = cmn.Err(
+	"block.name",
+	"The <block> element expects the name attribute.", "Element: %s",
+)
+
+var errorBlockDuplicate = cmn.Err(
+	"block.duplicate",
+	"The block is overridden more than once.", "Block: %s", "Element: %s",
+)
+
+var errorBlockUnknown = cmn.Err(
+	"block.unknown",
+	"The block does not exist in the layout.", "Block: %s", "Layout: %s", "Element: %s",
+)
+
+var errorBlockParentOrphan = cmn.Err(
+	"block.parent.orphan",
+	"The <block-parent> element must be inside a <block> that overrides a block of the layout.", "Element: %s",
+)
+
+// ExtendsElement `<extends href="layouts/base.html"><block name="content">...</block></extends>`
+//
+// The content of the layout replaces the <extends> element, the blocks of the layout (<block name="content">) are
+// replaced by the blocks of the page with the same name. The default content of the block is rendered by the
+// <block-parent> element. A layout can extend another layout, the blocks that the layout does not have are overridden
+// on the layout it extends.
+//
+// The inheritance is resolved at compile time, like the <link rel="include">.
+var ExtendsElement = &sht.Directive{
+	Name:     "extends",
+	Restrict: sht.ELEMENT,
+	Priority: 1000,
+	Terminal: true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) (*sht.DirectiveMethods, error) {
+		hrefAttr := attrs.Get("href")
+		if hrefAttr == "" {
+			return nil, errorExtendsHref(node.DebugTag())
+		}
+
+		currentFilepath := node.File
+		layoutFilepath, err := sht.ResolvePath(currentFilepath, hrefAttr)
+		if err != nil {
+			return nil, err
+		}
+
+		// the blocks overridden by the page
+		var names []string
+		blocks := map[string]*sht.Node{}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == sht.ElementNode && child.Data == "block" {
+				name := child.Attributes.Get("name")
+				if name == "" {
+					return nil, errorBlockName(child.DebugTag())
+				}
+				if _, exists := blocks[name]; exists {
+					return nil, errorBlockDuplicate(name, child.DebugTag())
+				}
+				names = append(names, name)
+				blocks[name] = child
+			} else if child.Type == sht.ElementNode || (child.Type == sht.TextNode && strings.TrimSpace(child.Data) != "") {
+				return nil, errorExtendsContent(child.DebugTag(), node.DebugTag())
+			}
+		}
+
+		// avoids cyclic inheritance (page > a > b > a). For each layout, the set of files that extended it
+		var parentsByFile map[string]sht.StringSet
+		if parentsI := c.Context.Get(keyExtendsParents); parentsI != nil {
+			parentsByFile = parentsI.(map[string]sht.StringSet)
+		} else {
+			parentsByFile = map[string]sht.StringSet{}
+			c.Context.Set(keyExtendsParents, parentsByFile)
+		}
+
+		parents := parentsByFile[currentFilepath]
+		if parents == nil {
+			parents = sht.StringSet{}
+		}
+		if layoutFilepath == currentFilepath || parents.Contains(layoutFilepath) {
+			return nil, errorExtendsCyclic(layoutFilepath, node.DebugTag())
+		}
+		parentsByFile[layoutFilepath] = parents.Clone(currentFilepath)
+
+		content, err := c.Load(layoutFilepath)
+		if err != nil {
+			return nil, errorExtendsLoad(layoutFilepath, node.DebugTag(), err.Error())
+		}
+		layoutNodes, err := sht.Parse(content, layoutFilepath)
+		if err != nil {
+			return nil, err
+		}
+
+		layout := &sht.Node{Type: sht.DocumentNode}
+		for _, layoutNode := range layoutNodes {
+			layoutNode.PrevSibling = nil
+			layoutNode.NextSibling = nil
+			layout.AppendChild(layoutNode)
+		}
+
+		overridden := map[string]bool{}
+		overrideBlocks(layout, blocks, overridden)
+
+		// the blocks that the layout does not have are passed to the layout it extends
+		var extends *sht.Node
+		layout.Transverse(func(child *sht.Node) bool {
+			if extends == nil && child.Type == sht.ElementNode && child.Data == "extends" {
+				extends = child
+			}
+			return extends != nil
+		})
+		for _, name := range names {
+			if overridden[name] {
+				continue
+			}
+			if extends == nil {
+				return nil, errorBlockUnknown(name, layoutFilepath, node.DebugTag())
+			}
+			blocks[name].Remove()
+			extends.AppendChild(blocks[name])
+		}
+
+		// the <extends> element becomes a container for the layout, which will be compiled as its children
+		c.SafeRemove(node)
+		node.Type = sht.DocumentNode
+		for _, layoutNode := range layout.GetChildNodes() {
+			layoutNode.Remove()
+			node.AppendChild(layoutNode)
+		}
+
+		return nil, nil
+	},
+}
+
+// overrideBlocks replaces the content of the blocks of the layout by the content of the blocks of the page
+func overrideBlocks(layout *sht.Node, blocks map[string]*sht.Node, overridden map[string]bool) {
+	for child := layout.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != sht.ElementNode {
+			continue
+		}
+		name := child.Attributes.Get("name")
+		if block, exists := blocks[name]; exists && child.Data == "block" && !overridden[name] {
+			overridden[name] = true
+
+			defaults := child.ExtractChildren()
+			for _, blockNode := range block.GetChildNodes() {
+				blockNode.Remove()
+				child.AppendChild(blockNode)
+			}
+
+			// <block-parent> renders the default content of the layout
+			var parents []*sht.Node
+			child.Transverse(func(node *sht.Node) bool {
+				if node.Type == sht.ElementNode && node.Data == "block-parent" {
+					parents = append(parents, node)
+					return true
+				}
+				return false
+			})
+			for _, parent := range parents {
+				parent.Type = sht.DocumentNode
+				parent.Data = ""
+				for defaultNode := defaults.FirstChild; defaultNode != nil; defaultNode = defaultNode.NextSibling {
+					parent.AppendChild(defaultNode.Clone())
+				}
+			}
+			continue
+		}
+		overrideBlocks(child, blocks, overridden)
+	}
+}
+
+// BlockElement `<block name="content">default content</block>`, a region of the layout that can be overridden by the
+// pages (see ExtendsElement). Only the content of the block is rendered.
+var BlockElement = &sht.Directive{
+	Name:     "block",
+	Restrict: sht.ELEMENT,
+	Priority: 1000,
+	Terminal: true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) (*sht.DirectiveMethods, error) {
+		if attrs.Get("name") == "" {
+			return nil, errorBlockName(node.DebugTag())
+		}
+		node.Type = sht.DocumentNode
+		node.Data = ""
+		return nil, nil
+	},
+}
+
+// BlockParentElement `<block-parent></block-parent>`, the ones used by the blocks that override the layout are
+// replaced by the default content of the block, any other is an orphan
+var BlockParentElement = &sht.Directive{
+	Name:     "block-parent",
+	Restrict: sht.ELEMENT,
+	Priority: 1000,
+	Terminal: true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) (*sht.DirectiveMethods, error) {
+		return nil, errorBlockParentOrphan(node.DebugTag())
+	},
+}
@@ -0,0 +1,78 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+	"testing"
+)
+
+func Test_Extends(t *testing.T) {
+	files := map[string]string{
+		"template.html": `
+    <extends href="layouts/page.html">
+      <block name="title">Home | <block-parent></block-parent></block>
+      <block name="main"><p>{message}</p></block>
+    </extends>`,
+		"layouts/page.html": `
+    <extends href="base.html">
+      <block name="content"><main><block name="main">Main</block></main><block-parent></block-parent></block>
+    </extends>`,
+		"layouts/base.html": `
+    <div class="page">
+      <h1><block name="title">Site</block></h1>
+      <block name="content"><footer>Footer</footer></block>
+    </div>`,
+	}
+
+	values := map[string]interface{}{"message": "Hello"}
+
+	expected := `
+    <div class="page">
+      <h1>Home | Site</h1>
+      <main><p>Hello</p></main><footer>Footer</footer>
+    </div>`
+
+	testTemplateFiles(t, files, values, expected)
+}
+
+func Test_Extends_should_render_layout_blocks(t *testing.T) {
+	files := map[string]string{
+		"template.html": `<div><block name="title">Site</block></div>`,
+	}
+	testTemplateFiles(t, files, nil, `<div>Site</div>`)
+}
+
+func Test_Extends_errors(t *testing.T) {
+	layout := map[string]string{"base.html": `<div><block name="content">Default</block></div>`}
+
+	testForErrorCode(t, `<extends></extends>`, "extends.href")
+	testForErrorCode(t, `<extends href="not-found.html"></extends>`, "extends.load")
+	testForErrorCode(t, `<extends href="../base.html"></extends>`, "path.traversal")
+	testForErrorCodeFiles(t, `<extends href="base.html"><block>A</block></extends>`, layout, "block.name")
+	testForErrorCodeFiles(t, `<extends href="base.html"><span>A</span></extends>`, layout, "extends.content")
+	testForErrorCodeFiles(t, `<extends href="base.html"><block name="content">A</block><block name="content">B</block></extends>`, layout, "block.duplicate")
+	testForErrorCodeFiles(t, `<extends href="base.html"><block name="other">A</block></extends>`, layout, "block.unknown")
+	testForErrorCode(t, `<div><block-parent></block-parent></div>`, "block.parent.orphan")
+}
+
+func Test_Extends_should_locate_duplicate_block(t *testing.T) {
+	ts := &sht.TemplateSystem{
+		Loader: testFileLoader(map[string]string{
+			"template.html": "<extends href=\"base.html\">\n<block name=\"content\">A</block>\n<block name=\"content\">B</block>\n</extends>",
+			"base.html":     `<main><block name="content"></block></main>`,
+		}),
+		Directives: testGDs.NewChild(),
+	}
+	_, _, err := ts.Compile("template.html")
+	if err == nil || !strings.HasPrefix(err.Error(), "[block.duplicate]") || !strings.Contains(err.Error(), "Line: 3") {
+		t.Errorf("compiler.Compile(template) | expect the error on the duplicate block\n   actual: %v", err)
+	}
+}
+
+func Test_Extends_should_not_allow_cyclic_inheritance(t *testing.T) {
+	testForErrorCode(t, `<extends href="template.html"></extends>`, "extends.cyclic")
+	testForErrorCodeFiles(t, `<extends href="a.html"></extends>`, map[string]string{
+		"a.html": `<extends href="b.html"></extends>`,
+		"b.html": `<extends href="a.html"></extends>`,
+	}, "extends.cyclic")
+}
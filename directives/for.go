@@ -0,0 +1,296 @@
+package directives
+
+import (
+	"fmt"
+	"github.com/syntax-framework/shtml/cmn"
+	"github.com/syntax-framework/shtml/sht"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errorForExpression = cmn.Err(
+	"for.expression",
+	"The element expects an expression in the form \"item in items\".", "Expression: %s", "Element: %s",
+)
+
+var errorForExpressionParse = cmn.Err(
+	"for.expression.parse",
+	"Error while parsing the expression of the element.", "Expression: %s", "Element: %s", "Cause: %s",
+)
+
+var errorForKeyParse = cmn.Err(
+	"for.key.parse",
+	"Error while parsing the key of the element.", "Key: %s", "Element: %s", "Cause: %s",
+)
+
+var errorForKeyDuplicate = cmn.Err(
+	"for.key.duplicate",
+	"The key is repeated, the items are compared by index.", "Key: %s", "Expression: %s", "Element: %s",
+)
+
+var errorForLimit = cmn.Err(
+	"for.limit",
+	"The limit must be a positive integer.", "Limit: %s", "Element: %s",
+)
+
+var errorForItemName = cmn.Err(
+	"for.item",
+	"The item name is reserved.", "Name: %s", "Element: %s",
+)
+
+// item in items
+var forExpressionRegex = regexp.MustCompile(`^\s*([a-zA-Z_$][a-zA-Z0-9_$]*)\s+in\s+(.+)$`)
+
+// forItemBindings values available on the scope of each item, in addition to the item itself
+var forItemBindings = map[string]bool{"index": true, "key": true, "first": true, "last": true}
+
+// forEntry an item of the iterated collection
+type forEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// compileFor parses the "item in items" expression and the optional key expression
+func compileFor(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler, attrName string) (*sht.DirectiveMethods, error) {
+	exp := attrs.Get(attrName)
+	match := forExpressionRegex.FindStringSubmatch(exp)
+	if match == nil {
+		return nil, errorForExpression(exp, node.DebugTag())
+	}
+
+	itemName := match[1]
+	if forItemBindings[itemName] {
+		return nil, errorForItemName(itemName, node.DebugTag())
+	}
+
+	collection, err := c.System.ParseExpression(match[2])
+	if err != nil {
+		return nil, errorForExpressionParse(exp, node.DebugTag(), err.Error())
+	}
+
+	// the values set on the scope of each item
+	keyType, itemType := forEntryTypes(c.CheckExpression(match[2], node))
+	c.DeclareLocal(itemName, itemType)
+	c.DeclareLocal("key", keyType)
+	c.DeclareLocal("index", reflect.TypeOf(0))
+	c.DeclareLocal("first", reflect.TypeOf(false))
+	c.DeclareLocal("last", reflect.TypeOf(false))
+
+	var keyExpression *sht.Expression
+	key := ""
+	if keyAttr := attrs.GetAttribute("key"); keyAttr != nil && strings.TrimSpace(keyAttr.Value) != "" {
+		keyExpression, err = c.System.ParseExpression(keyAttr.Value)
+		if err != nil {
+			return nil, errorForKeyParse(keyAttr.Value, node.DebugTag(), err.Error())
+		}
+		c.CheckExpression(keyAttr.Value, node)
+		key = keyAttr.Value
+	}
+
+	limit := 0
+	if limitAttr := attrs.GetAttribute("limit"); limitAttr != nil {
+		limit, err = strconv.Atoi(strings.TrimSpace(limitAttr.Value))
+		if err != nil || limit <= 0 {
+			return nil, errorForLimit(limitAttr.Value, node.DebugTag())
+		}
+	}
+
+	element := node.DebugTag()
+
+	// the expression is not rendered (<element for="item in items"/>), neither by the lower priority directives that
+	// also transclude the element (Ex. <li for="item in items" if="item.visible">)
+	attrs.Remove(attrs.GetAttribute(attrName))
+	attrs.Remove(attrs.GetAttribute("key"))
+	attrs.Remove(attrs.GetAttribute("limit"))
+
+	return createFor(attrName, itemName, collection.At(node), match[2], keyExpression.At(node), key, limit, element), nil
+}
+
+// restoreFor see sht.Directive.Restore
+func restoreFor(config map[string]interface{}, s *sht.TemplateSystem) (*sht.DirectiveMethods, error) {
+	attrName, _ := config["attr"].(string)
+	itemName, _ := config["item"].(string)
+	items, _ := config["items"].(string)
+	key, _ := config["key"].(string)
+	limit, _ := config["limit"].(float64)
+	element, _ := config["element"].(string)
+
+	collection, err := s.ParseExpression(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyExpression *sht.Expression
+	if key != "" {
+		if keyExpression, err = s.ParseExpression(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return createFor(attrName, itemName, collection, items, keyExpression, key, int(limit), element), nil
+}
+
+// createFor limit is the maximum number of items, 0 when unlimited
+func createFor(
+	attrName string, itemName string, collection *sht.Expression, items string, keyExpression *sht.Expression, key string,
+	limit int, element string,
+) *sht.DirectiveMethods {
+	return &sht.DirectiveMethods{
+		Config: map[string]interface{}{
+			"attr": attrName, "item": itemName, "items": items, "key": key, "limit": float64(limit), "element": element,
+		},
+		Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
+			entries := forEntries(collection.Exec(scope), limit)
+
+			rendered := &sht.Rendered{Dynamics: make([]interface{}, 0, len(entries))}
+			var keys map[string]bool
+			if keyExpression != nil {
+				rendered.Keys = make([]string, 0, len(entries))
+				keys = make(map[string]bool, len(entries))
+			}
+
+			last := len(entries) - 1
+			for index, entry := range entries {
+				var itemScope *sht.Scope
+				bind := func(s *sht.Scope) {
+					s.SetLocal(itemName, entry.value)
+					s.SetLocal("index", index)
+					s.SetLocal("key", entry.key)
+					s.SetLocal("first", index == 0)
+					s.SetLocal("last", index == last)
+					itemScope = s
+				}
+				item := transclude("", bind)
+
+				if keys != nil {
+					if itemScope == nil {
+						// element without content
+						bind(scope.New(false))
+					}
+					itemKey := keyExpression.EvalString(itemScope)
+					if keys[itemKey] {
+						// the items can no longer be identified by key (see sht.Diff)
+						scope.ReportError(errorForKeyDuplicate(itemKey, key, element))
+						keys = nil
+						rendered.Keys = nil
+					} else {
+						keys[itemKey] = true
+						rendered.Keys = append(rendered.Keys, itemKey)
+					}
+				}
+
+				if item != nil {
+					rendered.Assets = append(rendered.Assets, item.Assets...)
+					item.Assets = nil
+				}
+				rendered.Dynamics = append(rendered.Dynamics, item)
+			}
+
+			return rendered
+		},
+	}
+}
+
+// forEntryTypes the types of the key and of the item of the collection (see forEntries), nil when unknown
+func forEntryTypes(collection reflect.Type) (reflect.Type, reflect.Type) {
+	if collection == nil {
+		return nil, nil
+	}
+	switch collection.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan:
+		return reflect.TypeOf(0), collection.Elem()
+	case reflect.Map:
+		return collection.Key(), collection.Elem()
+	}
+	return nil, nil
+}
+
+// forEntries list the items of slices, arrays, maps (sorted by key) and channels (read until closed, blocking the
+// rendering while the channel is open). When limit > 0, only the first items are listed, the remaining values of the
+// channel are not read
+func forEntries(collection interface{}, limit int) []*forEntry {
+	if collection == nil {
+		return nil
+	}
+
+	var entries []*forEntry
+
+	value := reflect.ValueOf(collection)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len() && (limit <= 0 || i < limit); i++ {
+			entries = append(entries, &forEntry{key: i, value: value.Index(i).Interface()})
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return lessMapKey(keys[i], keys[j])
+		})
+		if limit > 0 && len(keys) > limit {
+			keys = keys[:limit]
+		}
+		for _, key := range keys {
+			entries = append(entries, &forEntry{key: key.Interface(), value: value.MapIndex(key).Interface()})
+		}
+	case reflect.Chan:
+		for i := 0; limit <= 0 || i < limit; i++ {
+			item, ok := value.Recv()
+			if !ok {
+				break
+			}
+			entries = append(entries, &forEntry{key: i, value: item.Interface()})
+		}
+	default:
+		// @TODO: Log.Warning
+		log.Printf("for: %T is not iterable", collection)
+	}
+
+	return entries
+}
+
+// lessMapKey deterministic order of map keys
+func lessMapKey(a reflect.Value, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
+// ForElement `<for each="item in items" key="item.id" limit="100"></for>`
+var ForElement = &sht.Directive{
+	Name:       "for",
+	Restrict:   sht.ELEMENT,
+	Priority:   1000,
+	Terminal:   true,
+	Transclude: true,
+	Stream:     true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, t *sht.Compiler) (*sht.DirectiveMethods, error) {
+		return compileFor(node, attrs, t, "each")
+	},
+	Restore: restoreFor,
+}
+
+// ForAttribute `<element for="item in items" key="item.id" limit="100"/>`
+var ForAttribute = &sht.Directive{
+	Name:       "for",
+	Restrict:   sht.ATTRIBUTE,
+	Priority:   1000,
+	Terminal:   true,
+	Transclude: "element",
+	Stream:     true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, t *sht.Compiler) (*sht.DirectiveMethods, error) {
+		return compileFor(node, attrs, t, "for")
+	},
+	Restore: restoreFor,
+}
@@ -0,0 +1,234 @@
+package directives
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+	"testing"
+)
+
+type testForItem struct {
+	Id   int
+	Name string
+}
+
+func Test_For_Element(t *testing.T) {
+
+	template := `
+    <ul>
+      <for each="item in items"><li>{index}:{item}{first ? ' first' : ''}{last ? ' last' : ''}</li></for>
+    </ul>`
+
+	values := map[string]interface{}{
+		"items": []string{"A", "B", "C"},
+	}
+
+	expected := `
+    <ul>
+      <li>0:A first</li><li>1:B</li><li>2:C last</li>
+    </ul>`
+
+	sht.TestTemplate(t, template, values, expected, testGDs)
+}
+
+func Test_For_Attribute(t *testing.T) {
+
+	template := `
+    <ul>
+      <li for="item in items" key="item.Id" class="item-{item.Id}">{item.Name}</li>
+    </ul>`
+
+	values := map[string]interface{}{
+		"items": []*testForItem{{Id: 1, Name: "A"}, {Id: 2, Name: "B"}},
+	}
+
+	expected := `
+    <ul>
+      <li class="item-1">A</li><li class="item-2">B</li>
+    </ul>`
+
+	rendered, _ := sht.TestTemplate(t, template, values, expected, testGDs)
+
+	// all items share the same fingerprint and are identified by key
+	list := rendered.Dynamics[0].(*sht.Rendered)
+	if len(list.Keys) != 2 || list.Keys[0] != "1" || list.Keys[1] != "2" {
+		t.Errorf("for | invalid keys\n   actual: %v\n expected: [1 2]", list.Keys)
+	}
+	first := list.Dynamics[0].(*sht.Rendered)
+	second := list.Dynamics[1].(*sht.Rendered)
+	if first.Fingerprint == "" || first.Fingerprint != second.Fingerprint {
+		t.Errorf("for | items must share the same fingerprint\n first: %q\n second: %q", first.Fingerprint, second.Fingerprint)
+	}
+}
+
+func Test_For_Map_And_Channel(t *testing.T) {
+
+	template := `<for each="value in values">[{key}={value}]</for>`
+
+	channel := make(chan int, 3)
+	channel <- 10
+	channel <- 20
+	close(channel)
+
+	var tests = []struct {
+		values   interface{}
+		expected string
+	}{
+		{map[string]int{"b": 2, "a": 1, "c": 3}, "[a=1][b=2][c=3]"},
+		{map[int]string{10: "x", 2: "y"}, "[2=y][10=x]"},
+		{[2]string{"x", "y"}, "[0=x][1=y]"},
+		{channel, "[0=10][1=20]"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		sht.TestTemplate(t, template, map[string]interface{}{"values": tt.values}, tt.expected, testGDs)
+	}
+}
+
+func Test_For_Limit(t *testing.T) {
+	template := `<for each="value in values" limit="2">[{key}={value}]</for>`
+
+	// the channel is never closed, only the first items are read
+	channel := make(chan int, 3)
+	channel <- 10
+	channel <- 20
+	channel <- 30
+
+	var tests = []struct {
+		values   interface{}
+		expected string
+	}{
+		{[]string{"x", "y", "z"}, "[0=x][1=y]"},
+		{map[string]int{"c": 3, "b": 2, "a": 1}, "[a=1][b=2]"},
+		{channel, "[0=10][1=20]"},
+	}
+	for _, tt := range tests {
+		sht.TestTemplate(t, template, map[string]interface{}{"values": tt.values}, tt.expected, testGDs)
+	}
+	if len(channel) != 1 {
+		t.Errorf("for | expect to keep the remaining values of the channel\n   actual: %d", len(channel))
+	}
+
+	testForErrorCode(t, `<for each="item in items" limit="0">A</for>`, "for.limit")
+	testForErrorCode(t, `<li for="item in items" limit="{max}">A</li>`, "for.limit")
+}
+
+func Test_For_Duplicate_Key(t *testing.T) {
+	ts := &sht.TemplateSystem{Directives: testGDs.NewChild(), Strict: true}
+	compiled, err := sht.NewCompiler(ts).Compile(`<li for="item in items" key="item.Id">{item.Name}</li>`, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := ts.NewScope()
+	scope.Set("items", []*testForItem{{Id: 1, Name: "A"}, {Id: 1, Name: "B"}})
+	rendered, err := compiled.Execute(scope)
+	if err == nil || !strings.HasPrefix(err.Error(), "[for.key.duplicate]") {
+		t.Errorf("compiled.Execute(scope) | invalid error\n expected: [for.key.duplicate] .......\n   actual: %v", err)
+	}
+
+	// compared by index
+	if list := rendered.Dynamics[0].(*sht.Rendered); list.Keys != nil {
+		t.Errorf("for | expect no keys\n   actual: %v", list.Keys)
+	}
+	if actual, expected := rendered.String(), `<li>A</li><li>B</li>`; actual != expected {
+		t.Errorf("compiled.Execute(scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+}
+
+func Test_For_Void_Element(t *testing.T) {
+
+	template := `<input for="item in items" value="{item}">`
+
+	values := map[string]interface{}{
+		"items": []string{"A", "B"},
+	}
+
+	sht.TestTemplate(t, template, values, `<input value="A"/><input value="B"/>`, testGDs)
+}
+
+func Test_For_Nested(t *testing.T) {
+
+	template := `<for each="row in rows"><for each="cell in row">{cell}</for>;</for>`
+
+	values := map[string]interface{}{
+		"rows": [][]int{{1, 2}, {3}},
+	}
+
+	sht.TestTemplate(t, template, values, `12;3;`, testGDs)
+}
+
+func Test_For_Errors(t *testing.T) {
+	testForErrorCode(t, `<for each="items">A</for>`, "for.expression")
+	testForErrorCode(t, `<li for="item of items">A</li>`, "for.expression")
+	testForErrorCode(t, `<for each="item in items[">A</for>`, "for.expression.parse")
+	testForErrorCode(t, `<for each="item in items" key="item.">A</for>`, "for.key.parse")
+	testForErrorCode(t, `<for each="index in items">A</for>`, "for.item")
+}
+
+func Test_For_If_Same_Element(t *testing.T) {
+	template := `<ul><li for="item in items" if="item > 1" class="item-{item}">{item}</li></ul>`
+	values := map[string]interface{}{"items": []int{1, 2, 3}}
+	expected := `<ul><li class="item-2">2</li><li class="item-3">3</li></ul>`
+	sht.TestTemplate(t, template, values, expected, testGDs)
+	testEncodedTemplateFiles(t, map[string]string{"template.html": template}, values, expected)
+}
+
+func Test_For_Diff(t *testing.T) {
+	compiled, _ := sht.TestCompile(t, `<ul><li for="item in items" key="item.Id">{item.Name}</li></ul>`, nil, testGDs)
+
+	render := func(items ...*testForItem) *sht.Rendered {
+		scope := sht.NewRootScope()
+		scope.Set("items", items)
+		return compiled.Exec(scope)
+	}
+
+	prev := render(&testForItem{Id: 1, Name: "A"}, &testForItem{Id: 2, Name: "B"})
+	next := render(&testForItem{Id: 2, Name: "B"}, &testForItem{Id: 1, Name: "A"}, &testForItem{Id: 3, Name: "C"})
+
+	diff := sht.Diff(prev, next)
+	list := diff.Dynamics[0].(*sht.RenderedDiff)
+	if len(list.Dynamics) != 1 || list.Dynamics[2] == nil {
+		t.Errorf("sht.Diff(prev, next) | expect only the new item, actual: %+v", list)
+	}
+	if actual, expected := sht.Apply(prev, diff).String(), next.String(); actual != expected {
+		t.Errorf("sht.Apply(prev, diff) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+}
+
+func Test_For_Render_Stream(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+
+	for _, template := range []string{
+		`<ul><for each="item in items"><li>{item}</li>{written(item)}</for></ul>`,
+		`<ul><li for="item in items">{item}{written(item)}</li></ul>`,
+	} {
+		compiled, _ := sht.TestCompile(t, template, nil, testGDs)
+
+		out := &bytes.Buffer{}
+		streamed := 0
+		scope := sht.NewRootScope()
+		scope.Set("items", items)
+		// the previous items are already written when the next is rendered
+		scope.Set("written", func(item int) string {
+			if strings.Contains(out.String(), fmt.Sprintf(">%d<", item-1)) || item == 0 {
+				streamed++
+			}
+			return ""
+		})
+
+		if err := compiled.Render(out, scope); err != nil {
+			t.Fatal(err)
+		}
+		if streamed != len(items) {
+			t.Errorf("compiled.Render(w, scope) | expect to write the items as they are rendered\n   actual: %d of %d", streamed, len(items))
+		}
+		if actual, expected := out.String(), compiled.Exec(scope).String(); actual != expected {
+			t.Errorf("compiled.Render(w, scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+		}
+	}
+}
@@ -1,122 +1,285 @@
 package directives
 
 import (
+	"github.com/syntax-framework/shtml/cmn"
 	"github.com/syntax-framework/shtml/sht"
-	"log"
 	"strings"
 )
 
-func createIfDirective(attrs *sht.Attributes, getValue func(attrs *sht.Attributes) string) *sht.DirectiveMethods {
-	cond := getValue(attrs)
+var errorIfOrphan = cmn.Err(
+	"if.orphan",
+	"The element must be immediately preceded by an <if> or <else-if> element.", "Element: %s",
+)
+
+var errorIfCond = cmn.Err(
+	"if.cond",
+	"The condition of the element was not found.", "Element: %s",
+)
+
+var errorIfCondParse = cmn.Err(
+	"if.cond.parse",
+	"Error while parsing the condition of the element.", "Cond: %s", "Element: %s", "Cause: %s",
+)
+
+// ifBranch an <else-if> or <else> that follows an <if>
+type ifBranch struct {
+	cond       string          // empty when <else>
+	expression *sht.Expression // nil when <else>
+	compiled   *sht.Compiled   // nil when empty
+}
+
+// ifChainSelector identifies the elements of an if/else-if/else chain
+type ifChainSelector struct {
+	isElseIf func(node *sht.Node) bool
+	isElse   func(node *sht.Node) bool
+	cond     func(node *sht.Node) string
+	// extract returns the node whose children are the content of the branch, detaching it from the template
+	extract func(node *sht.Node) *sht.Node
+}
+
+var elementChainSelector = &ifChainSelector{
+	isElseIf: func(node *sht.Node) bool { return node.Data == "else-if" },
+	isElse:   func(node *sht.Node) bool { return node.Data == "else" },
+	cond:     func(node *sht.Node) string { return node.Attributes.Get("cond") },
+	extract: func(node *sht.Node) *sht.Node {
+		return node.ExtractChildren()
+	},
+}
+
+var attributeChainSelector = &ifChainSelector{
+	isElseIf: func(node *sht.Node) bool { return node.Attributes.GetAttribute("else-if") != nil },
+	isElse:   func(node *sht.Node) bool { return node.Attributes.GetAttribute("else") != nil },
+	cond:     func(node *sht.Node) string { return node.Attributes.Get("else-if") },
+	extract: func(node *sht.Node) *sht.Node {
+		// the whole element is the content of the branch
+		node.Attributes.Remove(node.Attributes.GetAttribute("else-if"))
+		node.Attributes.Remove(node.Attributes.GetAttribute("else"))
+		holder := &sht.Node{Type: sht.DocumentNode}
+		holder.AppendChild(node.ReplaceByText())
+		return holder
+	},
+}
+
+// compileIfChain consumes the <else-if> and <else> siblings that follows the node, compiling the content of each one
+func compileIfChain(node *sht.Node, c *sht.Compiler, selector *ifChainSelector) ([]*ifBranch, error) {
+	var branches []*ifBranch
+
+	var gap []*sht.Node // whitespace between the elements of the chain
+	for next := node.NextSibling; next != nil; next = next.NextSibling {
+		if next.Type == sht.TextNode && strings.TrimSpace(next.Data) == "" {
+			gap = append(gap, next)
+			continue
+		}
+
+		if next.Type != sht.ElementNode {
+			break
+		}
+
+		isElse := selector.isElse(next)
+		if !isElse && !selector.isElseIf(next) {
+			break
+		}
+
+		branch := &ifBranch{}
+		if !isElse {
+			cond := selector.cond(next)
+			if strings.TrimSpace(cond) == "" {
+				return nil, errorIfCond(next.DebugTag())
+			}
+			expression, err := c.System.ParseExpression(cond)
+			if err != nil {
+				return nil, errorIfCondParse(cond, next.DebugTag(), err.Error())
+			}
+			c.CheckExpression(cond, next)
+			branch.cond = cond
+			branch.expression = expression.At(next)
+		}
+
+		compiled, err := c.CompileChildren(selector.extract(next))
+		if err != nil {
+			return nil, err
+		}
+		branch.compiled = compiled
+		branches = append(branches, branch)
+
+		for _, text := range gap {
+			c.SafeRemove(text)
+		}
+		gap = nil
+		c.SafeRemove(next)
+
+		if isElse {
+			break
+		}
+	}
+
+	return branches, nil
+}
+
+// restoreIfDirective see sht.Directive.Restore
+func restoreIfDirective(config map[string]interface{}, s *sht.TemplateSystem) (*sht.DirectiveMethods, error) {
+	attrName, _ := config["attr"].(string)
+	cond, _ := config["cond"].(string)
+
+	var branches []*ifBranch
+	items, _ := config["branches"].([]interface{})
+	for _, item := range items {
+		values, _ := item.(map[string]interface{})
+		branch := &ifBranch{}
+		branch.cond, _ = values["cond"].(string)
+		branch.compiled, _ = values["compiled"].(*sht.Compiled)
+		if branch.cond != "" {
+			expression, err := s.ParseExpression(branch.cond)
+			if err != nil {
+				return nil, err
+			}
+			branch.expression = expression
+		}
+		branches = append(branches, branch)
+	}
+
+	return createIfDirective(s, nil, attrName, cond, branches)
+}
+
+// createIfDirective the node is nil when restoring (see restoreIfDirective)
+func createIfDirective(system *sht.TemplateSystem, node *sht.Node, attrName string, cond string, branches []*ifBranch) (*sht.DirectiveMethods, error) {
+	element := ""
+	if node != nil {
+		element = node.DebugTag()
+	}
+
 	if strings.TrimSpace(cond) == "" {
-		log.Fatal("Atributo cond não encontrado para elemento if")
+		return nil, errorIfCond(element)
 	}
 
 	// @TODO: https://github.com/antonmedv/expr/blob/master/docs/Visitor-and-Patch.md
-	expression, err := sht.ParseExpression(cond)
+	expression, err := system.ParseExpression(cond)
 	if err != nil {
-		// @TODO: Remover todos os log.Fatal e simplesmente fazer log de warning
-		log.Fatal("sht.ParseExpression(cond)", err)
+		return nil, errorIfCondParse(cond, element, err.Error())
+	}
+	expression = expression.At(node)
+
+	var config []interface{}
+	for _, branch := range branches {
+		config = append(config, map[string]interface{}{"cond": branch.cond, "compiled": branch.compiled})
 	}
 
 	return &sht.DirectiveMethods{
+		Config: map[string]interface{}{"attr": attrName, "cond": cond, "branches": config},
 		Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
 			// If the attribute has changed since last Interpolate()
-			newCond := getValue(attrs)
+			newCond := attrs.Get(attrName)
+
+			// the condition is not rendered (<element if="true"/>)
+			attrs.Remove(attrs.GetAttribute(attrName))
 
+			// the compiled expression is shared by the concurrent renders, a changed condition is parsed on each render
+			current := expression
 			if newCond != cond {
 				// we need to interpolate again since the attribute value has been updated
 				// (e.g. by another directive's compile function)
 				// ensure unset/empty values make expression falsy
+				current = nil
 				if newCond != "" {
-					newExpression, err := sht.ParseExpression(newCond)
+					newExpression, err := system.ParseExpression(newCond)
 					if err != nil {
-						// @TODO: Log.Warning
-						log.Print(err)
-						expression = nil
+						scope.ReportError(err)
 					} else {
-						expression = newExpression
+						current = newExpression
 					}
-				} else {
-					expression = nil
 				}
-				cond = newCond
 			}
 
-			if expression.EvalBool(scope) {
+			if current.EvalBool(scope) {
 				return transclude("", nil)
-			} else {
-				return nil
 			}
-		},
-	}
-}
-
-func attrCOND(attrs *sht.Attributes) string {
-	return attrs.Get("cond")
-}
 
-func attrIF(attrs *sht.Attributes) string {
-	return attrs.Get("if")
+			// first truthy <else-if> or the <else>
+			for _, branch := range branches {
+				if branch.expression == nil || branch.expression.EvalBool(scope) {
+					if branch.compiled == nil {
+						return nil
+					}
+					return branch.compiled.Exec(scope)
+				}
+			}
+			return nil
+		},
+	}, nil
 }
 
-// IFElement `<if cond="true"/>`
-// @TODO `<if cond="true"></if> <else-if cond="true"></else-if> <else></else>`
+// IFElement `<if cond="true"></if> <else-if cond="true"></else-if> <else></else>`
 var IFElement = &sht.Directive{
 	Name:       "if",
 	Restrict:   sht.ELEMENT,
 	Priority:   900,
 	Terminal:   true,
 	Transclude: true,
+	Stream:     true,
 	Compile: func(node *sht.Node, attrs *sht.Attributes, t *sht.Compiler) (*sht.DirectiveMethods, error) {
-		return createIfDirective(attrs, attrCOND), nil
-
-		//conditions := &_CommandConditions{BreakOnFirstValid: true}
-		//
-		//nextSibling := node.NextSibling
-		//
-		//// if
-		//conditions.Add(CompileCondAttr(node, t), t.Transverse(t.ExtractRoot(node)))
-		//
-		//if nextSibling != nil && nextSibling.Type == html.ElementNode {
-		//	for nextNode := nextSibling; nextNode != nil; {
-		//		breakLoop := false
-		//		tag := strings.TrimSpace(nextNode.Data)
-		//		switch tag {
-		//		case "else-if":
-		//			aux := nextNode.NextSibling
-		//			conditions.Add(CompileCondAttr(nextNode, t), t.Transverse(t.ExtractRoot(nextNode)))
-		//			nextNode = aux
-		//			continue
-		//
-		//		case "else":
-		//			breakLoop = true
-		//			conditions.Add(trueBoolFunc, t.Transverse(t.ExtractRoot(nextNode)))
-		//			break
-		//
-		//		default:
-		//			breakLoop = true
-		//			break
-		//		}
-		//
-		//		if breakLoop {
-		//			break
-		//		}
-		//	}
-		//}
-		//
-		//t.replaceNodeByDynamic(node, conditions)
+		t.CheckExpression(attrs.Get("cond"), node)
+		branches, err := compileIfChain(node, t, elementChainSelector)
+		if err != nil {
+			return nil, err
+		}
+		return createIfDirective(t.System, node, "cond", attrs.Get("cond"), branches)
 	},
+	Restore: restoreIfDirective,
 }
 
-// IFAttribute `<element if="true"/>`
+// IFAttribute `<element if="true"/> <element else-if="true"/> <element else/>`
 var IFAttribute = &sht.Directive{
 	Name:       "if",
 	Restrict:   sht.ATTRIBUTE,
 	Priority:   899,
 	Terminal:   true,
 	Transclude: "element",
+	Stream:     true,
 	Compile: func(node *sht.Node, attrs *sht.Attributes, t *sht.Compiler) (*sht.DirectiveMethods, error) {
-		return createIfDirective(attrs, attrIF), nil
+		t.CheckExpression(attrs.Get("if"), node)
+		branches, err := compileIfChain(node, t, attributeChainSelector)
+		if err != nil {
+			return nil, err
+		}
+		return createIfDirective(t.System, node, "if", attrs.Get("if"), branches)
 	},
+	Restore: restoreIfDirective,
+}
+
+// orphanElseCompile all <else-if> and <else> consumed by an <if> are removed from the template before being visited
+// by the compiler, so any remaining one is an orphan
+func orphanElseCompile(node *sht.Node, attrs *sht.Attributes, t *sht.Compiler) (*sht.DirectiveMethods, error) {
+	return nil, errorIfOrphan(node.DebugTag())
+}
+
+// ElseIfElement `<else-if cond="true"/>` without a preceding <if>
+var ElseIfElement = &sht.Directive{
+	Name:     "else-if",
+	Restrict: sht.ELEMENT,
+	Priority: 900,
+	Compile:  orphanElseCompile,
+}
+
+// ElseElement `<else/>` without a preceding <if>
+var ElseElement = &sht.Directive{
+	Name:     "else",
+	Restrict: sht.ELEMENT,
+	Priority: 900,
+	Compile:  orphanElseCompile,
+}
+
+// ElseIfAttribute `<element else-if="true"/>` without a preceding <element if="">
+var ElseIfAttribute = &sht.Directive{
+	Name:     "else-if",
+	Restrict: sht.ATTRIBUTE,
+	Priority: 899,
+	Compile:  orphanElseCompile,
+}
+
+// ElseAttribute `<element else/>` without a preceding <element if="">
+var ElseAttribute = &sht.Directive{
+	Name:     "else",
+	Restrict: sht.ATTRIBUTE,
+	Priority: 899,
+	Compile:  orphanElseCompile,
 }
@@ -2,6 +2,7 @@ package directives
 
 import (
 	"github.com/syntax-framework/shtml/sht"
+	"strings"
 	"testing"
 )
 
@@ -44,3 +45,96 @@ func Test_IF_Element(t *testing.T) {
 
 	sht.TestTemplate(t, template, values, expected, testGDs)
 }
+
+func Test_IF_Else_Element(t *testing.T) {
+
+	template := `
+    <div>
+      <if cond="value == 1">A</if>
+      <else-if cond="value == 2">B</else-if>
+      <else-if cond="value == 3">C</else-if>
+      <else>D</else>
+      <if cond="value == 1">E</if> <else-if cond="value == 2">F</else-if>
+    </div>`
+
+	var tests = []struct {
+		value    int
+		expected string
+	}{
+		{1, "<div>\n  A\n  E\n</div>"},
+		{2, "<div>\n  B\n  F\n</div>"},
+		{3, "<div>\n  C\n  \n</div>"},
+		{4, "<div>\n  D\n  \n</div>"},
+	}
+	for _, tt := range tests {
+		sht.TestTemplate(t, template, map[string]interface{}{"value": tt.value}, tt.expected, testGDs)
+	}
+}
+
+func Test_IF_Else_Attribute(t *testing.T) {
+
+	template := `
+    <div>
+      <span if="value == 1" class="one">A</span>
+      <span else-if="value == 2" class="two">B</span>
+      <b else>C</b>
+    </div>`
+
+	var tests = []struct {
+		value    int
+		expected string
+	}{
+		{1, `<div>` + "\n  " + `<span class="one">A</span>` + "\n</div>"},
+		{2, `<div>` + "\n  " + `<span class="two">B</span>` + "\n</div>"},
+		{3, `<div>` + "\n  " + `<b>C</b>` + "\n</div>"},
+	}
+	for _, tt := range tests {
+		sht.TestTemplate(t, template, map[string]interface{}{"value": tt.value}, tt.expected, testGDs)
+	}
+}
+
+func Test_IF_Else_should_not_allow_orphan(t *testing.T) {
+	testForErrorCode(t, `<div><else>A</else></div>`, "if.orphan")
+	testForErrorCode(t, `<div><else-if cond="true">A</else-if></div>`, "if.orphan")
+	testForErrorCode(t, `<if cond="true">A</if><span>B</span><else>C</else>`, "if.orphan")
+	testForErrorCode(t, `<div><span else>A</span></div>`, "if.orphan")
+	testForErrorCode(t, `<div><span else-if="true">A</span></div>`, "if.orphan")
+}
+
+func Test_IF_Else_If_should_have_cond(t *testing.T) {
+	testForErrorCode(t, `<if cond="true">A</if><else-if>B</else-if>`, "if.cond")
+}
+
+func Test_IF_should_have_valid_cond(t *testing.T) {
+	testForErrorCode(t, `<if cond="">A</if>`, "if.cond")
+	testForErrorCode(t, `<span if=" ">A</span>`, "if.cond")
+	testForErrorCode(t, `<if cond="value +">A</if>`, "if.cond.parse")
+}
+
+func Test_IF_should_report_invalid_changed_cond(t *testing.T) {
+	ts := &sht.TemplateSystem{Directives: testGDs.NewChild(), Strict: true}
+
+	// changes the condition before the <element if=""> is processed
+	ts.Directives.Add(&sht.Directive{
+		Name:     "change-cond",
+		Restrict: sht.ATTRIBUTE,
+		Priority: 950,
+		Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
+			attrs.Set("if", "value +")
+			return nil
+		},
+	})
+
+	compiled, err := sht.NewCompiler(ts).Compile(`<span if="true" change-cond>A</span>`, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := compiled.Execute(ts.NewScope())
+	if err == nil || !strings.Contains(err.Error(), "value +") {
+		t.Errorf("compiled.Execute(scope) | expect to receive the parse error of the condition\n   actual: %v", err)
+	}
+	if actual := rendered.String(); actual != "" {
+		t.Errorf("compiled.Execute(scope) | invalid output\n   actual: %q\n expected: %q", actual, "")
+	}
+}
@@ -1,81 +1,114 @@
 package directives
 
 import (
+	"github.com/syntax-framework/shtml/cmn"
 	"github.com/syntax-framework/shtml/sht"
-	"log"
-	"path"
 	"strings"
 )
 
 const keyIncludeParents = "linkIncludes"
 
+var errorIncludeHref = cmn.Err(
+	"include.href",
+	"The <link rel=\"include\"> element expects the href attribute.", "Element: %s",
+)
+
+var errorIncludeExtension = cmn.Err(
+	"include.extension",
+	"Only .html files can be included.", "File: %s", "Element: %s",
+)
+
+var errorIncludeCyclic = cmn.Err(
+	"include.cyclic",
+	"Cyclic/recursive include identified.", "File: %s", "Element: %s",
+)
+
+var errorIncludeLoad = cmn.Err(
+	"include.load",
+	"Could not load the included file.", "File: %s", "Element: %s", "Cause: %s",
+)
+
 // LinkDirectiveFunc faz expr processamento de <link rel="include" href="file.html"/>
-var LinkDirectiveFunc = func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) {
+//
+// The content of the included file replaces the <link> element, keeping the location (File, Line, Column) of the
+// included file on nodes
+var LinkDirectiveFunc = func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) (*sht.DirectiveMethods, error) {
 	relAttr := attrs.Get("rel")
 	if relAttr != "include" {
-		return
+		return nil, nil
 	}
 
 	hrefAttr := attrs.Get("href")
 	if hrefAttr == "" {
-		log.Fatal("A tag <link rel=\"include\"> espera expr atributo href='string'")
+		return nil, errorIncludeHref(node.DebugTag())
 	}
 
 	currentFilepath := node.File
 
 	// Resolve expr path relativo ao documento atual
-	includeFilepath := path.Join(path.Dir(currentFilepath), hrefAttr)
+	includeFilepath, err := sht.ResolvePath(currentFilepath, hrefAttr)
+	if err != nil {
+		return nil, err
+	}
 
 	// @TODO: MARKDOWN, JS, CSS, TEXT, SVG?
 	if !strings.HasSuffix(includeFilepath, ".html") {
-		log.Fatal("Só é permitido expr include de arquivos .html")
+		return nil, errorIncludeExtension(includeFilepath, node.DebugTag())
 	}
 
-	// evita que sejam feitos includes cíclicos/recursivos
-	var parents sht.StringSet
+	// evita que sejam feitos includes cíclicos/recursivos. For each file, the set of files that included it
+	var parentsByFile map[string]sht.StringSet
 	parentsI := c.Context.Get(keyIncludeParents)
 	if parentsI != nil {
-		parents = parentsI.(sht.StringSet)
+		parentsByFile = parentsI.(map[string]sht.StringSet)
 	} else {
+		parentsByFile = map[string]sht.StringSet{}
+		c.Context.Set(keyIncludeParents, parentsByFile)
+	}
+
+	parents := parentsByFile[currentFilepath]
+	if parents == nil {
 		parents = sht.StringSet{}
-		c.Context.Set(keyIncludeParents, parents)
 	}
 
-	if parents.Contains(includeFilepath) {
-		//var linha = (template.substr(0, RegexMatch.index).split('\n').length);
-		//panic(msg + ' < arquivo: "' + filePath + '", linha: ' + linha + ' >');
-		//panic(msg + " <File: '" + filePath + "'" + ">")
-		//c.RaiseFileError("Cyclic/recursive include identified", includeFilepath)
+	if includeFilepath == currentFilepath || parents.Contains(includeFilepath) {
+		return nil, errorIncludeCyclic(includeFilepath, node.DebugTag())
 	}
 
-	// define algumas variáveis no escopo de processamento
-	//c.SetFilepath(includeFilepath)
-	c.Context.Set(keyIncludeParents, parents.Clone(currentFilepath))
+	// the children of the included file are processed right after this directive, so the new file only needs to know
+	// its own parents
+	parentsByFile[includeFilepath] = parents.Clone(currentFilepath)
 
 	// inclui e processa expr novo arquivo
-	//var includedContent, err = c.System.Load(includeFilepath)
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
-
-	//includedNode, err := c.Parse(includedContent)
-	//if err != nil {
-	//	log.Fatal(err)
-	//}
-	//c.Transverse(includedNode)
-	//
-	//node.Parent.InsertBefore(includedNode, node)
+	includedContent, err := c.Load(includeFilepath)
+	if err != nil {
+		return nil, errorIncludeLoad(includeFilepath, node.DebugTag(), err.Error())
+	}
+
+	includedNodes, err := sht.Parse(includedContent, includeFilepath)
+	if err != nil {
+		return nil, err
+	}
+
+	// the <link> element becomes a container for the included content, which will be compiled as its children
 	c.SafeRemove(node)
+	node.Type = sht.DocumentNode
+	for _, includedNode := range includedNodes {
+		includedNode.PrevSibling = nil
+		includedNode.NextSibling = nil
+		node.AppendChild(includedNode)
+	}
 
-	// restaura expr escopo de compilação
-	//c.SetFilepath(currentFilepath)
-	c.Context.Set(keyIncludeParents, parents)
+	return nil, nil
 }
 
+// LinkDirective `<link rel="include" href="file.html"/>`
+//
+// It is not Terminal, the other <link> elements keep their directives (Ex. attribute interpolation). Only when the
+// element is replaced by the included content the lower priority directives are skipped.
 var LinkDirective = &sht.Directive{
 	Name:     "link",
 	Restrict: sht.ELEMENT,
-	//Compile:  LinkDirectiveFunc,
-	Terminal: true,
+	Compile:  LinkDirectiveFunc,
 	Priority: 1000,
 }
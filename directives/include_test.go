@@ -0,0 +1,78 @@
+package directives
+
+import (
+	"testing"
+)
+
+func Test_Include(t *testing.T) {
+	files := map[string]string{
+		"template.html": `
+    <div>
+      <link rel="include" href="partials/header.html">
+      <span>{title}</span>
+    </div>`,
+		"partials/header.html": `<header><if cond="show">{title}</if> <link rel="include" href="menu.html"/></header>`,
+		"partials/menu.html":   `<nav>menu</nav>`,
+	}
+
+	values := map[string]interface{}{
+		"title": "Syntax",
+		"show":  true,
+	}
+
+	expected := `
+    <div>
+      <header>Syntax <nav>menu</nav></header>
+      <span>Syntax</span>
+    </div>`
+
+	testTemplateFiles(t, files, values, expected)
+}
+
+func Test_Include_should_keep_other_link_elements(t *testing.T) {
+	files := map[string]string{
+		"template.html": `<link rel="stylesheet" href="style.css">`,
+	}
+	testTemplateFiles(t, files, nil, `<link rel="stylesheet" href="style.css"/>`)
+}
+
+func Test_Include_should_interpolate_other_link_elements(t *testing.T) {
+	files := map[string]string{
+		"template.html": `<link rel="stylesheet" href="{url}">`,
+	}
+	values := map[string]interface{}{"url": "/css/style.css"}
+	testTemplateFiles(t, files, values, `<link rel="stylesheet" href="/css/style.css"/>`)
+}
+
+func Test_Include_should_skip_other_directives(t *testing.T) {
+	files := map[string]string{
+		"template.html": `<link rel="include" href="partial.html" class="{css}">`,
+		"partial.html":  `<b>partial</b>`,
+	}
+	testTemplateFiles(t, files, nil, `<b>partial</b>`)
+}
+
+func Test_Include_should_not_allow_cyclic_include(t *testing.T) {
+	template := `<div><link rel="include" href="a.html"></div>`
+	testForErrorCodeFiles(t, template, map[string]string{
+		"a.html": `<div><link rel="include" href="b.html"></div>`,
+		"b.html": `<div><link rel="include" href="a.html"></div>`,
+	}, "include.cyclic")
+}
+
+func Test_Include_should_not_allow_self_include(t *testing.T) {
+	testForErrorCode(t, `<link rel="include" href="template.html">`, "include.cyclic")
+}
+
+func Test_Include_errors(t *testing.T) {
+	testForErrorCode(t, `<link rel="include">`, "include.href")
+	testForErrorCode(t, `<link rel="include" href="style.css">`, "include.extension")
+	testForErrorCode(t, `<link rel="include" href="not-found.html">`, "include.load")
+}
+
+func Test_Include_should_not_allow_path_traversal(t *testing.T) {
+	testForErrorCode(t, `<link rel="include" href="../secret.html">`, "path.traversal")
+	testForErrorCodeFiles(t, `<link rel="include" href="partials/a.html">`, map[string]string{
+		"partials/a.html": `<link rel="include" href="../../secret.html">`,
+	}, "path.traversal")
+}
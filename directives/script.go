@@ -4,7 +4,6 @@ import (
 	"github.com/syntax-framework/shtml/jsc"
 	"github.com/syntax-framework/shtml/sht"
 	"log"
-	"path"
 	"strconv"
 	"strings"
 )
@@ -55,7 +54,11 @@ var Script = &sht.Directive{
 
 				assets = append(assets, asset.Name)
 			} else {
-				asset, err := t.RegisterAssetJsFilepath(path.Join(path.Dir(node.File), src))
+				filepath, err := sht.ResolvePath(node.File, src)
+				if err != nil {
+					return nil, err
+				}
+				asset, err := t.RegisterAssetJsFilepath(filepath)
 				if err != nil {
 					return nil, err
 				}
@@ -84,11 +87,31 @@ var Script = &sht.Directive{
 		node.FirstChild = nil
 		node.LastChild = nil
 
-		return &sht.DirectiveMethods{
-			Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
-				// This directive only tells Syntax that this script is required for rendering
-				return &sht.Rendered{Assets: assets}
-			},
-		}, nil
+		return createScript(assets), nil
+	},
+	Restore: func(config map[string]interface{}, s *sht.TemplateSystem) (*sht.DirectiveMethods, error) {
+		var assets []string
+		names, _ := config["assets"].([]interface{})
+		for _, name := range names {
+			if asset, isString := name.(string); isString {
+				assets = append(assets, asset)
+			}
+		}
+		return createScript(assets), nil
 	},
 }
+
+func createScript(assets []string) *sht.DirectiveMethods {
+	config := make([]interface{}, len(assets))
+	for i, asset := range assets {
+		config[i] = asset
+	}
+
+	return &sht.DirectiveMethods{
+		Config: map[string]interface{}{"assets": config},
+		Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
+			// This directive only tells Syntax that this script is required for rendering
+			return &sht.Rendered{Assets: assets}
+		},
+	}
+}
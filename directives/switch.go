@@ -0,0 +1,273 @@
+package directives
+
+import (
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	"github.com/syntax-framework/shtml/cmn"
+	"github.com/syntax-framework/shtml/sht"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var errorSwitchOn = cmn.Err(
+	"switch.on",
+	"The <switch> element expects the on attribute.", "Element: %s",
+)
+
+var errorSwitchExpressionParse = cmn.Err(
+	"switch.expression.parse",
+	"Error while parsing the expression of the element.", "Expression: %s", "Element: %s", "Cause: %s",
+)
+
+var errorSwitchCaseValue = cmn.Err(
+	"switch.case.value",
+	"The <case> element expects the value attribute.", "Element: %s",
+)
+
+var errorSwitchCaseDuplicate = cmn.Err(
+	"switch.case.duplicate",
+	"Duplicate case value.", "Value: %s", "Element: %s", "Previous: %s",
+)
+
+var errorSwitchDefaultMultiple = cmn.Err(
+	"switch.default.multiple",
+	"Multiple <default> elements.", "Element: %s", "Previous: %s",
+)
+
+var errorSwitchChild = cmn.Err(
+	"switch.child",
+	"The <switch> element only accepts <case> and <default> elements.", "Element: %s",
+)
+
+var errorSwitchOrphan = cmn.Err(
+	"switch.orphan",
+	"The element must be a child of a <switch> element.", "Element: %s",
+)
+
+const switchDefaultSlot = "default"
+
+// switchCase a <case> of a <switch>, rendered from the slot with the same name
+type switchCase struct {
+	slot       string
+	value      string
+	expression *sht.Expression
+}
+
+// SwitchElement `<switch on="expr"><case value="1"></case><default></default></switch>`
+var SwitchElement = &sht.Directive{
+	Name:       "switch",
+	Restrict:   sht.ELEMENT,
+	Priority:   900,
+	Terminal:   true,
+	Transclude: true,
+	Stream:     true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) (*sht.DirectiveMethods, error) {
+		on := attrs.Get("on")
+		if strings.TrimSpace(on) == "" {
+			return nil, errorSwitchOn(node.DebugTag())
+		}
+		onExpression, err := c.System.ParseExpression(on)
+		if err != nil {
+			return nil, errorSwitchExpressionParse(on, node.DebugTag(), err.Error())
+		}
+		c.CheckExpression(on, node)
+
+		var cases []*switchCase
+		var defaultNode *sht.Node
+		slots := map[string]*sht.Compiled{}
+		literals := map[string]*sht.Node{} // detection of duplicate cases
+
+		// the children are consumed by the switch, so they are never visited by the compiler
+		for _, child := range node.GetChildNodes() {
+			node.RemoveChild(child)
+
+			if child.Type == sht.CommentNode || (child.Type == sht.TextNode && strings.TrimSpace(child.Data) == "") {
+				continue
+			}
+
+			if child.Type != sht.ElementNode || (child.Data != "case" && child.Data != switchDefaultSlot) {
+				return nil, errorSwitchChild(child.DebugTag())
+			}
+
+			slot := switchDefaultSlot
+			if child.Data == "case" {
+				value := child.Attributes.Get("value")
+				if strings.TrimSpace(value) == "" {
+					return nil, errorSwitchCaseValue(child.DebugTag())
+				}
+				expression, err := c.System.ParseExpression(value)
+				if err != nil {
+					return nil, errorSwitchExpressionParse(value, child.DebugTag(), err.Error())
+				}
+				c.CheckExpression(value, child)
+
+				if literal, isLiteral := switchLiteral(value); isLiteral {
+					if previous, exists := literals[literal]; exists {
+						return nil, errorSwitchCaseDuplicate(value, child.DebugTag(), previous.DebugTag())
+					}
+					literals[literal] = child
+				}
+
+				slot = "case-" + strconv.Itoa(len(cases))
+				cases = append(cases, &switchCase{slot: slot, value: value, expression: expression.At(child)})
+			} else {
+				if defaultNode != nil {
+					return nil, errorSwitchDefaultMultiple(child.DebugTag(), defaultNode.DebugTag())
+				}
+				defaultNode = child
+			}
+
+			compiled, err := c.CompileChildren(child.ExtractChildren())
+			if err != nil {
+				return nil, err
+			}
+			if compiled != nil {
+				slots[slot] = compiled
+			}
+		}
+
+		methods := createSwitch(on, onExpression.At(node), cases, defaultNode != nil)
+		methods.Slots = slots
+		return methods, nil
+	},
+	Restore: func(config map[string]interface{}, s *sht.TemplateSystem) (*sht.DirectiveMethods, error) {
+		on, _ := config["on"].(string)
+		hasDefault, _ := config["default"].(bool)
+		onExpression, err := s.ParseExpression(on)
+		if err != nil {
+			return nil, err
+		}
+
+		var cases []*switchCase
+		items, _ := config["cases"].([]interface{})
+		for _, item := range items {
+			values, _ := item.(map[string]interface{})
+			cs := &switchCase{}
+			cs.slot, _ = values["slot"].(string)
+			cs.value, _ = values["value"].(string)
+			if cs.expression, err = s.ParseExpression(cs.value); err != nil {
+				return nil, err
+			}
+			cases = append(cases, cs)
+		}
+
+		return createSwitch(on, onExpression, cases, hasDefault), nil
+	},
+}
+
+func createSwitch(on string, onExpression *sht.Expression, cases []*switchCase, hasDefault bool) *sht.DirectiveMethods {
+	config := make([]interface{}, len(cases))
+	for i, cs := range cases {
+		config[i] = map[string]interface{}{"slot": cs.slot, "value": cs.value}
+	}
+
+	return &sht.DirectiveMethods{
+		Config: map[string]interface{}{"on": on, "cases": config, "default": hasDefault},
+		Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
+			value := onExpression.Exec(scope)
+			for _, cs := range cases {
+				if switchEquals(value, cs.expression.Exec(scope)) {
+					return transclude(cs.slot, nil)
+				}
+			}
+			if hasDefault {
+				return transclude(switchDefaultSlot, nil)
+			}
+			return nil
+		},
+	}
+}
+
+// switchLiteral when the expression is a literal (Ex. 1, 'a', true, nil), returns a normalized representation of its
+// value, used to identify duplicate cases at compile time
+func switchLiteral(exp string) (string, bool) {
+	tree, err := parser.Parse(exp)
+	if err != nil {
+		return "", false
+	}
+
+	node := tree.Node
+	negative := false
+	if unary, isUnary := node.(*ast.UnaryNode); isUnary && unary.Operator == "-" {
+		negative = true
+		node = unary.Node
+	}
+
+	switch n := node.(type) {
+	case *ast.IntegerNode:
+		return switchLiteralNumber(float64(n.Value), negative), true
+	case *ast.FloatNode:
+		return switchLiteralNumber(n.Value, negative), true
+	}
+
+	if negative {
+		return "", false
+	}
+
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return "s:" + n.Value, true
+	case *ast.BoolNode:
+		return "b:" + strconv.FormatBool(n.Value), true
+	case *ast.NilNode:
+		return "nil", true
+	}
+	return "", false
+}
+
+func switchLiteralNumber(value float64, negative bool) string {
+	if negative {
+		value = -value
+	}
+	return "n:" + strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// switchEquals compares the values, numbers are compared regardless of their type (Ex. int(1) == float64(1))
+func switchEquals(a interface{}, b interface{}) bool {
+	if na, isNumber := switchNumber(a); isNumber {
+		if nb, isNumber := switchNumber(b); isNumber {
+			return na == nb
+		}
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func switchNumber(value interface{}) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// orphanSwitchCompile all <case> and <default> of a <switch> are removed from the template before being visited by
+// the compiler, so any remaining one is an orphan
+func orphanSwitchCompile(node *sht.Node, attrs *sht.Attributes, t *sht.Compiler) (*sht.DirectiveMethods, error) {
+	return nil, errorSwitchOrphan(node.DebugTag())
+}
+
+// CaseElement `<case value="1"/>` outside a <switch>
+var CaseElement = &sht.Directive{
+	Name:     "case",
+	Restrict: sht.ELEMENT,
+	Priority: 900,
+	Compile:  orphanSwitchCompile,
+}
+
+// DefaultElement `<default/>` outside a <switch>
+var DefaultElement = &sht.Directive{
+	Name:     "default",
+	Restrict: sht.ELEMENT,
+	Priority: 900,
+	Compile:  orphanSwitchCompile,
+}
@@ -0,0 +1,56 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/sht"
+	"testing"
+)
+
+func Test_Switch(t *testing.T) {
+
+	template := `
+    <div>
+      <switch on="value">
+        <case value="1">A</case>
+        <case value="'two'">B</case>
+        <case value="other">C</case>
+        <default>D</default>
+      </switch>
+    </div>`
+
+	var tests = []struct {
+		value    interface{}
+		expected string
+	}{
+		{1, "<div>\n  A\n</div>"},
+		{1.0, "<div>\n  A\n</div>"},
+		{int64(1), "<div>\n  A\n</div>"},
+		{"two", "<div>\n  B\n</div>"},
+		{"x", "<div>\n  C\n</div>"},
+		{3, "<div>\n  D\n</div>"},
+	}
+	for _, tt := range tests {
+		values := map[string]interface{}{"value": tt.value, "other": "x"}
+		sht.TestTemplate(t, template, values, tt.expected, testGDs)
+	}
+}
+
+func Test_Switch_Without_Default(t *testing.T) {
+
+	template := `<switch on="value"><case value="1">A</case><case value="2"></case></switch>`
+
+	sht.TestTemplate(t, template, map[string]interface{}{"value": 1}, "A", testGDs)
+	sht.TestTemplate(t, template, map[string]interface{}{"value": 2}, "", testGDs)
+	sht.TestTemplate(t, template, map[string]interface{}{"value": 3}, "", testGDs)
+}
+
+func Test_Switch_Errors(t *testing.T) {
+	testForErrorCode(t, `<switch><case value="1">A</case></switch>`, "switch.on")
+	testForErrorCode(t, `<switch on="value"><case>A</case></switch>`, "switch.case.value")
+	testForErrorCode(t, `<switch on="value"><case value="1">A</case><case value="1.0">B</case></switch>`, "switch.case.duplicate")
+	testForErrorCode(t, `<switch on="value"><case value="'a'">A</case><case value="'a'">B</case></switch>`, "switch.case.duplicate")
+	testForErrorCode(t, `<switch on="value"><default>A</default><default>B</default></switch>`, "switch.default.multiple")
+	testForErrorCode(t, `<switch on="value"><span>A</span></switch>`, "switch.child")
+	testForErrorCode(t, `<switch on="value">A</switch>`, "switch.child")
+	testForErrorCode(t, `<div><case value="1">A</case></div>`, "switch.orphan")
+	testForErrorCode(t, `<div><default>A</default></div>`, "switch.orphan")
+}
@@ -22,10 +22,17 @@ func testFileLoader(files map[string]string) func(filepath string) (string, erro
 }
 
 func testForErrorCode(t *testing.T, template string, errorCode string) {
+	testForErrorCodeFiles(t, template, nil, errorCode)
+}
+
+// testForErrorCodeFiles same as testForErrorCode, allowing other files to be loaded by the template
+func testForErrorCodeFiles(t *testing.T, template string, files map[string]string, errorCode string) {
+	if files == nil {
+		files = map[string]string{}
+	}
+	files["template.html"] = sht.TestUnindentedTemplate(template)
 	ts := &sht.TemplateSystem{
-		Loader: testFileLoader(map[string]string{
-			"template.html": sht.TestUnindentedTemplate(template),
-		}),
+		Loader:     testFileLoader(files),
 		Directives: testGDs.NewChild(),
 	}
 	_, _, err := ts.Compile("template.html")
@@ -40,8 +47,65 @@ func testForErrorCode(t *testing.T, template string, errorCode string) {
 	}
 }
 
+// testTemplateFiles compiles the "template.html" file using an in memory file loader and tests the expected output
+func testTemplateFiles(t *testing.T, files map[string]string, values map[string]interface{}, expected string) {
+	for name, content := range files {
+		files[name] = sht.TestUnindentedTemplate(content)
+	}
+	ts := &sht.TemplateSystem{
+		Loader:     testFileLoader(files),
+		Directives: testGDs.NewChild(),
+	}
+	compiled, _, err := ts.Compile("template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sht.TestRender(t, compiled, values, expected)
+}
+
+// testEncodedTemplateFiles same as testTemplateFiles, rendering the template after encoding and decoding it on a new
+// system
+func testEncodedTemplateFiles(t *testing.T, files map[string]string, values map[string]interface{}, expected string) {
+	for name, content := range files {
+		files[name] = sht.TestUnindentedTemplate(content)
+	}
+	ts := &sht.TemplateSystem{
+		Loader:     testFileLoader(files),
+		Directives: testGDs.NewChild(),
+	}
+	compiled, _, err := ts.Compile("template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := compiled.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := &sht.TemplateSystem{Directives: testGDs.NewChild()}
+	decoded, err := decoder.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sht.TestRender(t, decoded, values, expected)
+}
+
 func init() {
 	testGDs.Add(IFElement)
 	testGDs.Add(IFAttribute)
+	testGDs.Add(ElseIfElement)
+	testGDs.Add(ElseElement)
+	testGDs.Add(ElseIfAttribute)
+	testGDs.Add(ElseAttribute)
+	testGDs.Add(ForElement)
+	testGDs.Add(ForAttribute)
+	testGDs.Add(SwitchElement)
+	testGDs.Add(CaseElement)
+	testGDs.Add(DefaultElement)
+	testGDs.Add(TranscludeElement)
 	testGDs.Add(Component)
+	testGDs.Add(LinkDirective)
+	testGDs.Add(ExtendsElement)
+	testGDs.Add(BlockElement)
+	testGDs.Add(BlockParentElement)
 }
@@ -0,0 +1,39 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/sht"
+)
+
+// TranscludeElement `<transclude slot="header">Fallback content</transclude>`
+//
+// Used in directive templates, renders the original content of the directive's element (or the named slot). When the
+// slot is empty, the content of the <transclude> element is rendered instead.
+var TranscludeElement = &sht.Directive{
+	Name:       "transclude",
+	Restrict:   sht.ELEMENT,
+	Priority:   1000,
+	Terminal:   true,
+	Transclude: true,
+	Compile: func(node *sht.Node, attrs *sht.Attributes, c *sht.Compiler) (*sht.DirectiveMethods, error) {
+		return createTransclude(attrs.Get("slot")), nil
+	},
+	Restore: func(config map[string]interface{}, s *sht.TemplateSystem) (*sht.DirectiveMethods, error) {
+		slot, _ := config["slot"].(string)
+		return createTransclude(slot), nil
+	},
+}
+
+func createTransclude(slot string) *sht.DirectiveMethods {
+	return &sht.DirectiveMethods{
+		Config: map[string]interface{}{"slot": slot},
+		Process: func(scope *sht.Scope, attrs *sht.Attributes, transclude sht.TranscludeFunc) *sht.Rendered {
+			if transcludeFn := scope.Transclude(); transcludeFn != nil {
+				if rendered := transcludeFn(slot, nil); rendered != nil && (rendered.Static != nil || len(rendered.Dynamics) > 0) {
+					return rendered
+				}
+			}
+			// fallback content
+			return transclude("", nil)
+		},
+	}
+}
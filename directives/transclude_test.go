@@ -0,0 +1,80 @@
+package directives
+
+import (
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_Transclude_Template(t *testing.T) {
+
+	directives := testGDs.NewChild()
+	directives.Add(&sht.Directive{
+		Name:     "card",
+		Restrict: sht.ELEMENT,
+		Template: `<h1><transclude slot="title">Untitled</transclude></h1><div><transclude/></div>`,
+		Transclude: map[string]string{
+			"title": "?card-title",
+		},
+	})
+	directives.Add(&sht.Directive{
+		Name:         "alert",
+		Restrict:     sht.ATTRIBUTE,
+		Replace:      true,
+		TemplatePath: "alert.html",
+		Assets: fstest.MapFS{
+			"alert.html": {Data: []byte(`<p class="alert">{message}: <transclude>empty</transclude></p>`)},
+		},
+	})
+
+	template := `
+    <div>
+      <card class="card"><card-title>Title {value}</card-title>Content {value}</card>
+      <card>Content</card>
+      <span alert>Text</span>
+      <span alert></span>
+    </div>`
+
+	expected := `
+    <div>
+      <card class="card"><h1>Title X</h1><div>Content X</div></card>
+      <card><h1>Untitled</h1><div>Content</div></card>
+      <p class="alert">Message: Text</p>
+      <p class="alert">Message: empty</p>
+    </div>`
+
+	values := map[string]interface{}{
+		"value":   "X",
+		"message": "Message",
+	}
+
+	sht.TestTemplate(t, template, values, expected, directives)
+}
+
+func Test_Transclude_TemplatePath_System(t *testing.T) {
+
+	directives := testGDs.NewChild()
+	directives.Add(&sht.Directive{
+		Name:         "widget",
+		Restrict:     sht.ELEMENT,
+		TemplatePath: "widget.html",
+	})
+
+	files := map[string]string{
+		"template.html": `<widget>A</widget>`,
+		"widget.html":   `<b><transclude/></b>`,
+	}
+	ts := &sht.TemplateSystem{Loader: testFileLoader(files), Directives: directives}
+	compiled, _, err := ts.Compile("template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sht.TestRender(t, compiled, nil, `<widget><b>A</b></widget>`)
+
+	delete(files, "widget.html")
+	ts.Invalidate("widget.html")
+	if _, _, err = ts.Compile("template.html"); err == nil || !strings.HasPrefix(err.Error(), "[directive.template.load]") {
+		t.Errorf("ts.Compile(template) | expect to receive [directive.template.load] error, got: %v", err)
+	}
+}
@@ -5,7 +5,6 @@ go 1.18
 require (
 	github.com/antonmedv/expr v1.9.0
 	github.com/cespare/xxhash v1.1.0
-	github.com/erinpentecost/byteline v1.0.0
 	github.com/iancoleman/strcase v0.2.0
 	golang.org/x/net v0.0.0-20220708220712-1185a9018129
 )
@@ -2,6 +2,7 @@ package jsc
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/syntax-framework/shtml/cmn"
 	"github.com/syntax-framework/shtml/sht"
@@ -91,8 +92,17 @@ func init() {
 	eventVariableScope = &ast.BlockStmt.Scope
 }
 
-// Compile does all the necessary handling to link the template with javascript
+// Compile does all the necessary handling to link the template with javascript. The default values of the params of
+// a component are evaluated without the functions of a TemplateSystem, see CompileComponent
 func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Sequence) (asset *Javascript, err error) {
+	return CompileComponent(nodeParent, nodeScript, sequenceGlobal, nil)
+}
+
+// CompileComponent same as Compile, using the params of the component already parsed (see ParseComponentParams), whose
+// default values were evaluated (see NodeComponentParams.EvalDefaults)
+func CompileComponent(
+	nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Sequence, componentParams *NodeComponentParams,
+) (asset *Javascript, err error) {
 
 	if nodeScript != nil && nodeScript.Attributes.Get("src") != "" {
 		// jsc only works with inline scripts, any external script must be handled by specialized module
@@ -158,11 +168,13 @@ func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Seq
 	watchers := &cmn.IndexedSet{}
 
 	// parse component params (<element param-name="type" client-param-name="type">)
-	var componentParams *NodeComponentParams
-	if nodeParentIsComponent {
+	if nodeParentIsComponent && componentParams == nil {
 		if componentParams, err = ParseComponentParams(nodeParent); err != nil {
 			return nil, err
 		}
+		if err = componentParams.EvalDefaults(&sht.TemplateSystem{}, nodeParent.Attributes.Get("name")); err != nil {
+			return nil, err
+		}
 	}
 
 	// parse references to elements within the template (<element ref="myJsVariable">)
@@ -192,14 +204,16 @@ func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Seq
 		compJsParams := &bytes.Buffer{}
 		compJsParams.WriteString("\n    // parameters")
 		compJsParams.WriteString("\n    const _$params = $.params;\n")
-		for _, jsParam := range componentParams.ClientParams {
-			name := jsParam.Name
-			compJsParams.WriteString(fmt.Sprintf("    let %s = _$params['%s'];\n", name, name))
+		values := make([]string, len(componentParams.ClientParams))
+		for i, jsParam := range componentParams.ClientParams {
+			if values[i], err = clientParamValue(jsParam, nodeParent); err != nil {
+				return nil, err
+			}
+			compJsParams.WriteString(fmt.Sprintf("    let %s = %s;\n", jsParam.Name, values[i]))
 		}
 		compJsParams.WriteString("\n    $.p(() => {\n")
-		for _, jsParam := range componentParams.ClientParams {
-			name := jsParam.Name
-			compJsParams.WriteString(fmt.Sprintf("      %s = _$params['%s'];\n", name, name))
+		for i, jsParam := range componentParams.ClientParams {
+			compJsParams.WriteString(fmt.Sprintf("      %s = %s;\n", jsParam.Name, values[i]))
 		}
 		compJsParams.WriteString("    });\n")
 		jsSource = compJsParams.String()
@@ -245,7 +259,7 @@ func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Seq
 	// @TODO: fork the project https://github.com/tdewolff/parse/tree/master/js and add feature to keep original formatting
 	jsSource = contextJsAst.JS()
 
-	expressionsErr := (&ExpressionsParser{
+	expressionsParser := &ExpressionsParser{
 		Node:               nodeParent,
 		Sequence:           sequence,
 		ContextAst:         contextJsAst,
@@ -259,7 +273,8 @@ func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Seq
 		Writers:            writers,
 		Watchers:           watchers,
 		NodeIdentifierFunc: getNodeIdentifier,
-	}).Parse()
+	}
+	expressionsErr := expressionsParser.Parse()
 	if expressionsErr != nil {
 		return nil, expressionsErr // @TODO: Custom error or Warning
 	}
@@ -475,6 +490,7 @@ func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Seq
 	jsCode := &Javascript{
 		Content: bjs.String(),
 		//ComponentParams: ClientParams,
+		Events: expressionsParser.PushEvents,
 	}
 
 	if nodeScript != nil {
@@ -485,6 +501,19 @@ func Compile(nodeParent *sht.Node, nodeScript *sht.Node, sequenceGlobal *sht.Seq
 	return jsCode, nil
 }
 
+// clientParamValue the javascript code that gets the value of the parameter, applying the default value when informed
+// (the evaluated cmn.ComponentParam.DefaultValue, as JSON)
+func clientParamValue(param cmn.ComponentParam, node *sht.Node) (string, error) {
+	if param.HasDefault {
+		value, err := json.Marshal(param.DefaultValue)
+		if err != nil {
+			return "", errorCompParamDefaultJson(param.Name, param.Default, node.DebugTag(), err.Error())
+		}
+		return fmt.Sprintf("_$params['%s'] ?? %s", param.Name, value), nil
+	}
+	return fmt.Sprintf("_$params['%s']", param.Name), nil
+}
+
 // parseExportApi All exports are transformed in the component's API, and can be accessed by the "ref" attribute
 //
 // All exports from the JS file are collected and made available in the "return" of the instance
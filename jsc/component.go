@@ -17,6 +17,17 @@ var errorCompClientParamInvalidName = cmn.Err(
 	"The parameter name is invalid.", `Param: client-param-%s="@%s"`, "Component: %s",
 )
 
+var errorCompParamDefault = cmn.Err(
+	"component.param.default",
+	"The default value of the parameter is empty.", `Param: %s="%s"`, "Component: %s",
+)
+
+var errorCompParamDefaultJson = cmn.Err(
+	"component.param.default.json",
+	"The default value of the parameter cannot be converted to javascript.", "Param: %s", "Default: %s", "Component: %s",
+	"Cause: %s",
+)
+
 type NodeComponentParams struct {
 	ServerParams       []cmn.ComponentParam // server params
 	ClientParams       []cmn.ComponentParam // javascript params
@@ -39,7 +50,8 @@ func ParseComponentParams(node *sht.Node) (*NodeComponentParams, error) {
 	refParamsValueOrig := map[string]string{}
 	clientParamsToResolve := map[string]*cmn.ComponentParam{}
 
-	for name, attr := range node.Attributes.Map {
+	for _, attr := range node.Attributes.List() {
+		name := attr.Normalized
 		isParam, isClientParam, paramName := strings.HasPrefix(name, "param-"), false, ""
 		if isParam {
 			paramName = strcase.ToLowerCamel(strings.Replace(name, "param-", "", 1))
@@ -63,6 +75,17 @@ func ParseComponentParams(node *sht.Node) (*NodeComponentParams, error) {
 					paramTypeName = paramTypeName[1:]
 				}
 
+				// default value (Ex. param-size="?number = 12")
+				if idx := strings.IndexByte(paramTypeName, '='); idx >= 0 {
+					param.Default = strings.TrimSpace(paramTypeName[idx+1:])
+					if param.Default == "" {
+						return nil, errorCompParamDefault(name, attr.Value, node.DebugTag())
+					}
+					param.HasDefault = true
+					param.Required = false
+					paramTypeName = strings.TrimSpace(paramTypeName[:idx])
+				}
+
 				if isClientParam && strings.HasPrefix(paramTypeName, "@") {
 					// is exposing a parameter to JS, by reference
 					// Ex. <component param-name="string" client-param-name="@name" />
@@ -74,6 +97,10 @@ func ParseComponentParams(node *sht.Node) (*NodeComponentParams, error) {
 						param.Type = serverParam.Type
 						param.TypeName = serverParam.TypeName
 						param.Reference = serverParam
+						if !param.HasDefault {
+							param.Default = serverParam.Default
+							param.HasDefault = serverParam.HasDefault
+						}
 					} else {
 						// will solve further below
 						clientParamsToResolve[referenceName] = &param
@@ -114,6 +141,10 @@ func ParseComponentParams(node *sht.Node) (*NodeComponentParams, error) {
 			jsParam.Type = serverParam.Type
 			jsParam.TypeName = serverParam.TypeName
 			jsParam.Reference = serverParam
+			if !jsParam.HasDefault {
+				jsParam.Default = serverParam.Default
+				jsParam.HasDefault = serverParam.HasDefault
+			}
 		} else {
 			// Error, is referencing a non-existent parameter
 			return nil, errorCompClientParamReferenceNotFound(
@@ -122,6 +153,12 @@ func ParseComponentParams(node *sht.Node) (*NodeComponentParams, error) {
 		}
 	}
 
+	// the client params were resolved by reference
+	for i := range clientParams {
+		clientParams[i] = *clientParamsByName[clientParams[i].Name]
+		clientParamsByName[clientParams[i].Name] = &clientParams[i]
+	}
+
 	return &NodeComponentParams{
 		ServerParams:       serverParams,
 		ClientParams:       clientParams,
@@ -130,3 +167,16 @@ func ParseComponentParams(node *sht.Node) (*NodeComponentParams, error) {
 		ClientParamsByName: clientParamsByName,
 	}, nil
 }
+
+// EvalDefaults evaluates the default values of the client params, written to the javascript of the component (see
+// sht.TemplateSystem.ParamDefault)
+func (p *NodeComponentParams) EvalDefaults(system *sht.TemplateSystem, component string) error {
+	for i := range p.ClientParams {
+		if p.ClientParams[i].HasDefault {
+			if err := system.ParamDefault(component, &p.ClientParams[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
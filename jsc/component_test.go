@@ -0,0 +1,55 @@
+package jsc
+
+import (
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+	"testing"
+)
+
+func Test_ParseComponentParams_default(t *testing.T) {
+	nodes, err := sht.Parse(
+		`<component name="test" param-size="?number = 12" param-title="string" client-param-size="@size" client-param-open="?bool = false" client-param-label="?string = 'a' + 'b'"></component>`,
+		"template.html",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := ParseComponentParams(nodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := params.ServerParamsByName["size"]
+	if size.Required || !size.HasDefault || size.Default != "12" || size.TypeName != "number" {
+		t.Errorf("ParseComponentParams(node) | invalid param-size: %+v", size)
+	}
+
+	title := params.ServerParamsByName["title"]
+	if !title.Required || title.HasDefault {
+		t.Errorf("ParseComponentParams(node) | invalid param-title: %+v", title)
+	}
+
+	if err = params.EvalDefaults(&sht.TemplateSystem{}, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// client params inherit the default value from the referenced param, the evaluated value is written as JSON
+	for name, expected := range map[string]string{
+		"size":  "_$params['size'] ?? 12",
+		"open":  "_$params['open'] ?? false",
+		"label": `_$params['label'] ?? "ab"`,
+	} {
+		if value, _ := clientParamValue(*params.ClientParamsByName[name], nodes[0]); value != expected {
+			t.Errorf("clientParamValue(param) | invalid output\n   actual: %s\n expected: %s", value, expected)
+		}
+	}
+}
+
+func Test_ParseComponentParams_empty_default(t *testing.T) {
+	nodes, _ := sht.Parse(`<component name="test" param-size="?number ="></component>`, "template.html")
+	_, err := ParseComponentParams(nodes[0])
+	if err == nil || !strings.HasPrefix(err.Error(), "[component.param.default]") {
+		t.Errorf("ParseComponentParams(node) | expect to receive [component.param.default] error, got: %v", err)
+	}
+}
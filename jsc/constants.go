@@ -9,6 +9,7 @@ import (
 type Javascript struct {
 	Content         string
 	ComponentParams []cmn.ComponentParam
+	Events          map[string]int // the events pushed to the server by the template and their number of arguments
 }
 
 // HtmlEventsPush list of events that are enabled by default to push to server
@@ -25,8 +25,20 @@ type ExpressionsParser struct {
 	Writers            *cmn.IndexedSet
 	Watchers           *cmn.IndexedSet
 	NodeIdentifierFunc func(node *sht.Node) string
+	PushEvents         map[string]int // events pushed to the server, by name, and the number of arguments
 }
 
+var errorJsEventName = cmn.Err(
+	"js.event.name",
+	"The first argument of push must be the name of the event (string literal).", "Expression: (%s)", "Element: %s",
+)
+
+var errorJsEventArgs = cmn.Err(
+	"js.event.args",
+	"The event is pushed with a different number of arguments.",
+	"Event: %s", "Arguments: %d", "Previous: %d", "Element: %s",
+)
+
 var errorJsInterpolationSideEffect = cmn.Err(
 	"js:interpolation:sideeffect",
 	"Expressions with Side Effect in text interpolation block or attributes are not allowed.",
@@ -74,7 +86,8 @@ func (p *ExpressionsParser) Parse() error {
 			}
 		} else if child.Type == sht.ElementNode {
 			// busca interpolação nos atributos
-			for attrNameNormalized, attr := range child.Attributes.Map {
+			for _, attr := range child.Attributes.List() {
+				attrNameNormalized := attr.Normalized
 				if strings.HasPrefix(attrNameNormalized, "on") {
 					if eventErr := p.parseAttributeEvent(child, attr); eventErr != nil {
 						err = eventErr
@@ -147,15 +160,31 @@ func (p *ExpressionsParser) parseAttributeEvent(child *sht.Node, attr *sht.Attri
 					// considers it to be a remote eventIdx call (push)
 					functionName := jsVar.String()
 					eventName := functionName
-					eventPayload := ""
+					args := callExpr.Args.List
 					if functionName == "push" {
 						// <button onclick="push('increment', count, time, e.MouseX)" data-ref="mySpan">
-					} else {
-						// <button onclick="increment(count, time, e.MouseX)">
+						var nameLiteral *js.LiteralExpr
+						if len(args) > 0 {
+							nameLiteral, _ = args[0].Value.(*js.LiteralExpr)
+						}
+						if nameLiteral == nil || nameLiteral.TokenType != js.StringToken {
+							return errorJsEventName(eventJsCode, child.DebugTag())
+						}
+						eventName = string(nameLiteral.Data[1 : len(nameLiteral.Data)-1])
+						args = args[1:]
+					}
+					// <button onclick="increment(count, time, e.MouseX)">
+					if err := p.addPushEvent(eventName, len(args), child); err != nil {
+						return err
+					}
 
+					// the arguments are serialized in the payload, the event (e) is only available on the client
+					payload := make([]string, len(args))
+					for i, arg := range args {
+						payload[i] = arg.JS()
 					}
 					// AddDispatcers(interpolationJsAst, contextAstScope, contextVariables, nil)
-					eventJsCode = "(e) => { push('" + eventName + "', e, " + eventPayload + ") }"
+					eventJsCode = "(e) => { push('" + eventName + "', e, [" + strings.Join(payload, ", ") + "]) }"
 				}
 			} else {
 				log.Println("[@TODO] UNKNOWN: what to do? At jsc.parseAttributeEvent(*sht.NodeTest, *sht.Attribute)")
@@ -170,7 +199,10 @@ func (p *ExpressionsParser) parseAttributeEvent(child *sht.Node, attr *sht.Attri
 			} else {
 				// considers it to be a remote eventIdx call (push)
 				// <button onclick="increment"></button>
-				eventJsCode = "(e) => { push('" + jsVar.String() + "', e) }"
+				if err := p.addPushEvent(jsVar.String(), 0, child); err != nil {
+					return err
+				}
+				eventJsCode = "(e) => { push('" + jsVar.String() + "', e, []) }"
 			}
 		case *js.ArrowFunc:
 			// <element onclick="(e) => doSomething">
@@ -201,6 +233,18 @@ func (p *ExpressionsParser) parseAttributeEvent(child *sht.Node, attr *sht.Attri
 	return nil
 }
 
+// addPushEvent registers an event pushed to the server, all the calls of the event must have the same number of arguments
+func (p *ExpressionsParser) addPushEvent(name string, args int, child *sht.Node) error {
+	if p.PushEvents == nil {
+		p.PushEvents = map[string]int{}
+	}
+	if previous, exists := p.PushEvents[name]; exists && previous != args {
+		return errorJsEventArgs(name, args, previous, child.DebugTag())
+	}
+	p.PushEvents[name] = args
+	return nil
+}
+
 // parseAttribute faz processamento dos bindings de atributos (writers e eventos para two way data binding)
 func (p *ExpressionsParser) parseAttribute(child *sht.Node, attr *sht.Attribute) error {
 	sequence := p.Sequence
@@ -457,7 +501,7 @@ func (p *ExpressionsParser) parseTextNode(child *sht.Node) error {
 			File:       child.File,
 			Line:       child.Line,
 			Column:     child.Column,
-			Attributes: &sht.Attributes{Map: map[string]*sht.Attribute{}},
+			Attributes: sht.NewAttributes(),
 		}
 		anchor.Attributes.Set("hidden", "hidden")
 		anchor.Attributes.Set("id", elementId)
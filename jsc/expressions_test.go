@@ -0,0 +1,70 @@
+package jsc
+
+import (
+	"github.com/syntax-framework/shtml/sht"
+	"strings"
+	"testing"
+)
+
+func testCompileComponentJs(t *testing.T, template string) (*Javascript, error) {
+	nodes, err := sht.Parse(strings.TrimSpace(template), "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script *sht.Node
+	nodes[0].Transverse(func(node *sht.Node) (stop bool) {
+		if node.Data == "script" {
+			script = node
+		}
+		return false
+	})
+	return Compile(nodes[0], script, &sht.Sequence{})
+}
+
+func Test_push_events(t *testing.T) {
+	asset, err := testCompileComponentJs(t, `
+    <component name="counter">
+      <button onclick="increment(count, 2)">+</button>
+      <button onclick="push('save', count)">save</button>
+      <button onclick="reset">reset</button>
+      <script>let count = 0;</script>
+    </component>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"(e) => { push('increment', e, [count, 2]) }",
+		"(e) => { push('save', e, [count]) }",
+		"(e) => { push('reset', e, []) }",
+	} {
+		if !strings.Contains(asset.Content, expected) {
+			t.Errorf("Compile(nodeParent, nodeScript, Sequence) | expect %q\n   actual: %s", expected, asset.Content)
+		}
+	}
+
+	if asset.Events["increment"] != 2 || asset.Events["save"] != 1 || asset.Events["reset"] != 0 || len(asset.Events) != 3 {
+		t.Errorf("Compile(nodeParent, nodeScript, Sequence) | invalid events: %v", asset.Events)
+	}
+}
+
+func Test_push_events_errors(t *testing.T) {
+	_, err := testCompileComponentJs(t, `
+    <component name="counter">
+      <button onclick="increment(count)">+</button>
+      <button onclick="increment()">+</button>
+      <script>let count = 0;</script>
+    </component>`)
+	if err == nil || !strings.HasPrefix(err.Error(), "[js.event.args]") {
+		t.Errorf("Compile(nodeParent, nodeScript, Sequence) | invalid error\n expected: [js.event.args] .......\n   actual: %v", err)
+	}
+
+	_, err = testCompileComponentJs(t, `
+    <component name="counter">
+      <button onclick="push(count)">+</button>
+      <script>let count = 0;</script>
+    </component>`)
+	if err == nil || !strings.HasPrefix(err.Error(), "[js.event.name]") {
+		t.Errorf("Compile(nodeParent, nodeScript, Sequence) | invalid error\n expected: [js.event.name] .......\n   actual: %v", err)
+	}
+}
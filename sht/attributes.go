@@ -2,7 +2,6 @@ package sht
 
 import (
 	"bytes"
-	"sort"
 	"strings"
 )
 
@@ -14,9 +13,15 @@ type Attribute struct {
 	Normalized string // name normalized
 }
 
-// Attributes abstração dos atributos de um Node html
+// Attributes abstração dos atributos de um Node html, keeps the order of the source (see List)
 type Attributes struct {
-	Map map[string]*Attribute
+	Map  map[string]*Attribute // by normalized name, for lookup. Changes must use Add, Set and Remove (see List)
+	list []*Attribute          // the order of the attributes
+}
+
+// NewAttributes creates an empty set of attributes
+func NewAttributes() *Attributes {
+	return &Attributes{Map: map[string]*Attribute{}}
 }
 
 func NewAttribute(name string, value string, namespace string) *Attribute {
@@ -28,21 +33,47 @@ func NewAttribute(name string, value string, namespace string) *Attribute {
 	}
 }
 
+// Add adds the attribute at the end, replacing the attribute with the same normalized name in its position
+func (a *Attributes) Add(attr *Attribute) {
+	if a.Map == nil {
+		a.Map = map[string]*Attribute{}
+	}
+	if previous, exists := a.Map[attr.Normalized]; exists {
+		for i, listed := range a.list {
+			if listed == previous {
+				a.list[i] = attr
+			}
+		}
+	} else {
+		a.list = append(a.list, attr)
+	}
+	a.Map[attr.Normalized] = attr
+}
+
+// List the attributes in the order of the source, the attributes added later are at the end. The list must not be
+// modified, use Add, Set and Remove
+func (a *Attributes) List() []*Attribute {
+	return a.list
+}
+
 // Clone Uso interno, faz uma cópia dos atributos para ser usado em tempo de execução
 func (a *Attributes) Clone() *Attributes {
-	attributes := map[string]*Attribute{}
-	if a.Map != nil {
-		for nameN, attr := range a.Map {
-			attributes[nameN] = &Attribute{
-				Name:       attr.Name,
-				Value:      attr.Value,
-				Namespace:  attr.Namespace,
-				Normalized: attr.Normalized,
-			}
+	attributes := &Attributes{
+		Map:  make(map[string]*Attribute, len(a.list)),
+		list: make([]*Attribute, len(a.list)),
+	}
+	for i, attr := range a.list {
+		clone := &Attribute{
+			Name:       attr.Name,
+			Value:      attr.Value,
+			Namespace:  attr.Namespace,
+			Normalized: attr.Normalized,
 		}
+		attributes.Map[clone.Normalized] = clone
+		attributes.list[i] = clone
 	}
 
-	return &Attributes{Map: attributes}
+	return attributes
 }
 
 func (a *Attributes) GetAttribute(name string) *Attribute {
@@ -74,7 +105,7 @@ func (a *Attributes) Set(key string, value string) {
 	} else {
 		attr := NewAttribute(key, value, "")
 		if attr.Normalized != "" {
-			a.Map[attr.Normalized] = attr
+			a.Add(attr)
 		}
 	}
 }
@@ -125,20 +156,21 @@ func (a *Attributes) RemoveClass(value string) {
 	}
 }
 
-// Render the attributes sorted by name. Boolean attributes (no value) are always rendered at the end
-//  &Rendered{
-//    Static: *[]string{
-//      ` attribute-one="`,
-//      `"  attribute-two="`,
-//      `" class="`,
-//      `" boolean-attr-one boolean-attr-2`,
-//    }
-//    Dynamics: []interface{}{
-//      "value-1",
-//      "value-2"
-//      "class-1 class-",
-//    }
-//}
+// Render the attributes in the order of the source (see List)
+//
+//	&Rendered{
+//	  Static: *[]string{
+//	    ` attribute-one="`,
+//	    `" boolean-attr-one attribute-two="`,
+//	    `" class="`,
+//	    `" boolean-attr-2`,
+//	  }
+//	  Dynamics: []interface{}{
+//	    "value-1",
+//	    "value-2"
+//	    "class-1 class-",
+//	  }
+//	}
 func (a *Attributes) Render() *Rendered {
 	if a.Map == nil {
 		return nil
@@ -147,73 +179,39 @@ func (a *Attributes) Render() *Rendered {
 	var static []string
 	var dynamics []interface{}
 
-	// Attributes sorted
-	//  [
-	//    ["attr-one", "value"],
-	//    ["attr-two", "value"],
-	//    ["attr-bool"],
-	//  ]
-	var sortedAttributes [][]string
-	for _, a := range a.Map {
-		attrName := a.Name
-		if a.Namespace != "" {
-			attrName = a.Namespace + ":" + attrName
+	prevHasValue := false
+	staticCurr := &bytes.Buffer{}
+	for _, attr := range a.list {
+		attrName := attr.Name
+		if attr.Namespace != "" {
+			attrName = attr.Namespace + ":" + attrName
 		}
 
+		hasValue := attr.Value != ""
 		if HtmlBooleanAttributes[attrName] == true {
 			// https://html.spec.whatwg.org/#boolean-attribute
-			if a.Value != "false" {
-				sortedAttributes = append(sortedAttributes, []string{attrName})
-			}
-		} else {
-			if a.Value != "" {
-				// attr-name, value
-				sortedAttributes = append(sortedAttributes, []string{attrName, HtmlEscape(a.Value)})
-			} else {
-				sortedAttributes = append(sortedAttributes, []string{attrName})
+			if attr.Value == "false" {
+				continue
 			}
+			hasValue = false
 		}
-	}
 
-	sort.Slice(sortedAttributes, func(i, j int) bool {
-		a := sortedAttributes[i]
-		b := sortedAttributes[j]
-		// boolean attributes at the end
-		if len(b) < len(a) {
-			// a != boolean
-			// b == boolean
-			// a first
-			return true
-		}
-		if len(a) < len(b) {
-			// a == boolean
-			// b != boolean
-			// b first
-			return false
-		}
-
-		return strings.Compare(a[0], b[0]) <= 0
-	})
-
-	prevHasValue := false
-	staticCurr := &bytes.Buffer{}
-	for _, part := range sortedAttributes {
 		if prevHasValue {
 			staticCurr.WriteRune('"')
 		}
 		staticCurr.WriteByte(' ')
-		if len(part) == 2 {
+		if hasValue {
 			// attr-name="value"
 			prevHasValue = true
-			staticCurr.WriteString(part[0] + `="`)
+			staticCurr.WriteString(attrName + `="`)
 			static = append(static, staticCurr.String())
 			// reset buffer (next Static)
 			staticCurr = &bytes.Buffer{}
-			dynamics = append(dynamics, part[1])
+			dynamics = append(dynamics, HtmlEscape(attr.Value))
 		} else {
 			// attr-name
 			prevHasValue = false
-			staticCurr.WriteString(part[0])
+			staticCurr.WriteString(attrName)
 		}
 	}
 
@@ -232,5 +230,11 @@ func (a *Attributes) Render() *Rendered {
 func (a *Attributes) Remove(attr *Attribute) {
 	if attr != nil {
 		delete(a.Map, attr.Normalized)
+		for i, listed := range a.list {
+			if listed == attr {
+				a.list = append(a.list[:i:i], a.list[i+1:]...)
+				break
+			}
+		}
 	}
 }
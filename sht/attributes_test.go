@@ -0,0 +1,48 @@
+package sht
+
+import (
+	"testing"
+)
+
+func Test_Attributes_Order(t *testing.T) {
+	template := `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="5"><meta name="viewport" content="width=device-width" charset="utf-8"/></svg>`
+
+	compiled, _ := TestCompile(t, template, nil, &Directives{})
+	TestRender(t, compiled, nil, template)
+	testEncodeDecode(t, compiled, &Directives{}, nil, template)
+
+	nodes, err := Parse(`<input value="a" type="text" disabled id="b" class="c">`, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := nodes[0].Attributes
+
+	clone := attrs.Clone()
+	clone.Remove(clone.GetAttribute("type"))
+	clone.Set("value", "changed")
+	clone.Set("placeholder", "new")
+
+	for _, tt := range []struct {
+		attrs    *Attributes
+		expected string
+	}{
+		{attrs, ` value="a" type="text" disabled id="b" class="c"`},
+		{clone, ` value="changed" disabled id="b" class="c" placeholder="new"`},
+	} {
+		if actual := tt.attrs.Render().String(); actual != tt.expected {
+			t.Errorf("attrs.Render() | invalid output\n   actual: %s\n expected: %s", actual, tt.expected)
+		}
+	}
+
+	// Map is the index of List by normalized name
+	for _, a := range []*Attributes{attrs, clone} {
+		if len(a.Map) != len(a.List()) {
+			t.Errorf("attrs.Map | expect the same size of List\n   actual: %d\n expected: %d", len(a.Map), len(a.List()))
+		}
+		for _, attr := range a.List() {
+			if a.Map[attr.Normalized] != attr {
+				t.Errorf("attrs.Map[%s] | expect the attribute of List\n   actual: %v", attr.Normalized, a.Map[attr.Normalized])
+			}
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package sht
+
+import (
+	"github.com/syntax-framework/shtml/cmn"
+	"io/fs"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheEntry a template compiled by TemplateSystem.Compile and what it depends on
+type cacheEntry struct {
+	compiled   *Compiled
+	context    *Context
+	files      map[string]time.Time  // the files read by the compilation (template, includes, scripts) and their mod time
+	components map[string]*Component // the components used by the template that were declared in other files
+}
+
+// StatFS the Stat of the files of the fsys, used by the dev mode of the TemplateSystem
+//
+// Ex. system.Stat = sht.StatFS(os.DirFS("templates"))
+func StatFS(fsys fs.FS) func(filepath string) (fs.FileInfo, error) {
+	return func(filepath string) (fs.FileInfo, error) {
+		return fs.Stat(fsys, filepath)
+	}
+}
+
+// Compile compiles the file, the result is cached by path.
+//
+// In dev mode (TemplateSystem.Dev), the modification time of the files used by the template (the template itself,
+// includes and script files) is checked through TemplateSystem.Stat (or the FS), only the templates whose files were modified are
+// recompiled. A template is also recompiled when a component it uses was redeclared, the templates that declare the
+// components are checked first.
+func (s *TemplateSystem) Compile(filepath string) (*Compiled, *Context, error) {
+	if s.Dev && s.Stat == nil && s.FS == nil {
+		return nil, nil, errorCompileDev()
+	}
+	return s.compileCached(filepath, map[string]bool{})
+}
+
+var errorCompileDev = cmn.Err(
+	"compile.dev",
+	"The dev mode requires the TemplateSystem.Stat or the TemplateSystem.FS to check the modification of the files.",
+)
+
+// Invalidate removes from the cache all the templates that depend on the file, which will be recompiled on the next
+// call to Compile. Allows the use of a file watcher when not in dev mode.
+func (s *TemplateSystem) Invalidate(filepath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for path, entry := range s.cache {
+		if _, depends := entry.files[filepath]; depends {
+			delete(s.cache, path)
+		}
+	}
+}
+
+// compileCall a compilation in progress, the concurrent calls of Compile for the same path wait for its result
+type compileCall struct {
+	done     chan struct{}
+	compiled *Compiled
+	context  *Context
+	err      error
+}
+
+// compileCached visiting are the templates being checked, avoids cycles between templates that use components declared
+// by each other
+func (s *TemplateSystem) compileCached(filepath string, visiting map[string]bool) (*Compiled, *Context, error) {
+	visiting[filepath] = true
+
+	s.mu.RLock()
+	entry := s.cache[filepath]
+	s.mu.RUnlock()
+	if entry != nil && (!s.Dev || !s.outdated(entry, visiting)) {
+		return entry.compiled, entry.context, nil
+	}
+
+	s.mu.Lock()
+	if current := s.cache[filepath]; current != nil && current != entry {
+		// compiled concurrently
+		s.mu.Unlock()
+		return current.compiled, current.context, nil
+	}
+	if call, inFlight := s.compiling[filepath]; inFlight {
+		s.mu.Unlock()
+		<-call.done
+		return call.compiled, call.context, call.err
+	}
+	call := &compileCall{done: make(chan struct{}), err: errorCompileInterrupted(filepath)}
+	if s.compiling == nil {
+		s.compiling = map[string]*compileCall{}
+	}
+	s.compiling[filepath] = call
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.compiling, filepath)
+		s.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.compiled, call.context, call.err = s.compileEntry(filepath)
+	return call.compiled, call.context, call.err
+}
+
+var errorCompileInterrupted = cmn.Err(
+	"compile.interrupted",
+	"The concurrent compilation of the template was interrupted.", "File: %s",
+)
+
+// compileEntry compiles the template and caches the result
+func (s *TemplateSystem) compileEntry(filepath string) (*Compiled, *Context, error) {
+	compiler, compiled, err := s.compile(filepath, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &cacheEntry{
+		compiled:   compiled,
+		context:    compiler.Context,
+		files:      compiler.files,
+		components: map[string]*Component{},
+	}
+	for name, component := range compiler.components {
+		// declared by the template itself or by its includes, are redeclared on the recompilation
+		if _, declared := entry.files[component.File]; !declared {
+			entry.components[name] = component
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = map[string]*cacheEntry{}
+	}
+	s.cache[filepath] = entry
+
+	return compiled, compiler.Context, nil
+}
+
+// outdated checks if any file of the entry was modified or if any component used was redeclared
+func (s *TemplateSystem) outdated(entry *cacheEntry, visiting map[string]bool) bool {
+	outdated := false
+	for file, modTime := range entry.files {
+		if !s.modTime(file).Equal(modTime) {
+			outdated = true
+		}
+	}
+
+	for name, component := range entry.components {
+		// refreshes the templates that declare the component
+		var declaring []string
+		s.mu.RLock()
+		for path, other := range s.cache {
+			if _, declares := other.files[component.File]; declares && !visiting[path] {
+				declaring = append(declaring, path)
+			}
+		}
+		s.mu.RUnlock()
+		for _, path := range declaring {
+			// the errors are reported when the declaring template is compiled directly
+			_, _, _ = s.compileCached(path, visiting)
+		}
+
+		s.mu.RLock()
+		redeclared := s.Components[name] != component
+		s.mu.RUnlock()
+		if redeclared {
+			outdated = true
+		}
+	}
+	return outdated
+}
+
+var errorCompileAllFS = cmn.Err(
+	"compile.fs",
+	"The TemplateSystem has no FS to list the templates.",
+)
+
+var errorCompileAllPattern = cmn.Err(
+	"compile.pattern",
+	"Invalid pattern.", "Pattern: %s",
+)
+
+var errorCompileAllFile = cmn.Err(
+	"compile.file",
+	"Error while compiling the template.", "File: %s", "Cause: %s",
+)
+
+// CompileAll compiles the files of the TemplateSystem.FS that match the patterns (see path.Match), a pattern without
+// "/" matches the name of the file in any directory. The files are compiled by a pool of GOMAXPROCS workers, all errors
+// are returned at once (cmn.ErrorList).
+//
+// The patterns are compiled in order, the files of a pattern are compiled only after all the files of the previous
+// patterns, which allows the components to be declared before they are used.
+//
+// Ex. system.CompileAll("components/*.html", "*.html")
+func (s *TemplateSystem) CompileAll(patterns ...string) (map[string]*Compiled, error) {
+	if s.FS == nil {
+		return nil, errorCompileAllFS()
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errorCompileAllPattern(pattern)
+		}
+	}
+
+	groups := make([][]string, len(patterns))
+	err := fs.WalkDir(s.FS, ".", func(filepath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		for i, pattern := range patterns {
+			name := filepath
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(filepath)
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				groups[i] = append(groups[i], filepath)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := map[string]*Compiled{}
+	var errs cmn.ErrorList
+	for _, files := range groups {
+		results := make([]*Compiled, len(files))
+		failures := make([]error, len(files))
+
+		jobs := make(chan int)
+		wg := sync.WaitGroup{}
+		for w := 0; w < runtime.GOMAXPROCS(0) && w < len(files); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i], _, failures[i] = s.Compile(files[i])
+				}
+			}()
+		}
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		for i, file := range files {
+			if failures[i] != nil {
+				errs = append(errs, errorCompileAllFile(file, failures[i].Error()))
+			} else {
+				compiled[file] = results[i]
+			}
+		}
+	}
+
+	return compiled, errs.Err()
+}
+
+// modTime the modification time of the file, zero when there is no Stat or FS or the file does not exist
+func (s *TemplateSystem) modTime(filepath string) time.Time {
+	stat := s.Stat
+	if stat == nil && s.FS != nil {
+		stat = StatFS(s.FS)
+	}
+	if stat == nil {
+		return time.Time{}
+	}
+	info, err := stat(filepath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Load loads a file used by the template being compiled (Ex. include), recording it as a dependency of the template
+// (see TemplateSystem.Compile)
+func (c *Compiler) Load(filepath string) (string, error) {
+	c.dependOn(filepath)
+	return c.System.Load(filepath)
+}
+
+// dependOn records the modification time of the file before it is read, a modification during the compilation
+// causes a new compilation
+func (c *Compiler) dependOn(filepath string) {
+	if c.files == nil {
+		c.files = map[string]time.Time{}
+	}
+	if _, exists := c.files[filepath]; !exists {
+		c.files[filepath] = c.System.modTime(filepath)
+	}
+}
+
+// useComponent records a component used by the template
+func (c *Compiler) useComponent(name string, component *Component) {
+	if c.components == nil {
+		c.components = map[string]*Component{}
+	}
+	c.components[name] = component
+}
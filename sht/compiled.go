@@ -4,11 +4,10 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"github.com/syntax-framework/shtml/cmn"
+	"io"
 	"strings"
 )
 
-// @TODO: Transform to serializable structure to allow binary build. https://stackoverflow.com/a/19763256/2204014
-
 // Compiled structure representing a Compiled template
 type Compiled struct {
 	Assets      []*cmn.Asset // Reference to all resources that can be used by this compiled
@@ -46,6 +45,57 @@ func (c *Compiled) Exec(scope *Scope) *Rendered {
 	return out
 }
 
+// Execute same as Exec, also returning the errors of the expressions when the scope is strict (see Scope.SetStrict).
+// The Rendered is always returned, the failed expressions render empty.
+func (c *Compiled) Execute(scope *Scope) (*Rendered, error) {
+	start := len(scope.Errors())
+	rendered := c.Exec(scope)
+	return rendered, cmn.ErrorList(scope.takeErrors(start)).Err()
+}
+
+// Render renders the Compiled directly to w, without building the whole Rendered tree. The static parts are written as
+// the dynamics are evaluated, allowing large pages to be streamed (Ex. http.ResponseWriter) with bounded memory.
+//
+// flushAfter are the points of the static parts where w is flushed (http.Flusher, *bufio.Writer), right after they are
+// written (Ex. "</head>"). Rendering stops on the first write error, which is returned. When the scope is strict, the
+// errors of the expressions are returned after the whole Compiled is written (see Execute).
+//
+// The assets required by the dynamic parts are not collected, use Compiled.Assets.
+func (c *Compiled) Render(w io.Writer, scope *Scope, flushAfter ...string) error {
+	start := len(scope.Errors())
+	out := &renderWriter{w: w, flushAfter: flushAfter}
+	c.render(out, scope)
+	errs := scope.takeErrors(start)
+	if out.err != nil {
+		return out.err
+	}
+	return cmn.ErrorList(errs).Err()
+}
+
+func (c *Compiled) render(out *renderWriter, scope *Scope) {
+	for i := 0; i < len(c.static) && out.err == nil; i++ {
+		if i > 0 {
+			switch dynamic := c.dynamics[i-1].(type) {
+			case *DynamicCompiled:
+				// no need to build the Rendered
+				if dynamic.Compiled != nil {
+					dynamic.Compiled.render(out, scope)
+				}
+			case *DynamicDirectives:
+				dynamic.render(out, scope)
+			default:
+				result := dynamic.Exec(scope)
+				if compiled, isCompiled := result.(*Compiled); isCompiled && compiled != nil {
+					compiled.render(out, scope)
+				} else {
+					out.writeDynamic(result)
+				}
+			}
+		}
+		out.writeStatic(c.static[i])
+	}
+}
+
 // Fingerprint GetIndex static fingerprint
 func (c *Compiled) Fingerprint() string {
 	if c.fingerprint == "" {
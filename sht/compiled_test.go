@@ -0,0 +1,72 @@
+package sht
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testFlushWriter records the content written until each flush
+type testFlushWriter struct {
+	bytes.Buffer
+	flushes []string
+}
+
+func (w *testFlushWriter) Flush() {
+	w.flushes = append(w.flushes, w.String())
+}
+
+// testFailWriter fails after writing limit bytes
+type testFailWriter struct {
+	written int
+	limit   int
+}
+
+func (w *testFailWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		return 0, errors.New("write failed")
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func Test_Render(t *testing.T) {
+	template := `
+    <html>
+      <head><title>{title}</title></head>
+      <body class="{title}">!{content}</body>
+    </html>`
+
+	expected := TestUnindentedTemplate(`
+    <html>
+      <head><title>Title</title></head>
+      <body class="Title"><b>Content</b></body>
+    </html>`)
+
+	compiled, _ := TestCompile(t, template, nil, &Directives{})
+	scope := NewRootScope()
+	scope.Set("title", "Title")
+	scope.Set("content", "<b>Content</b>")
+
+	w := &testFlushWriter{}
+	if err := compiled.Render(w, scope, "</head>"); err != nil {
+		t.Fatal(err)
+	}
+	if actual := w.String(); actual != expected {
+		t.Errorf("compiled.Render(w, scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+	if len(w.flushes) != 1 || w.flushes[0] != "<html>\n  <head><title>Title</title></head>" {
+		t.Errorf("compiled.Render(w, scope) | invalid flushes\n   actual: %q", w.flushes)
+	}
+	if actual := compiled.Exec(scope).String(); actual != expected {
+		t.Errorf("compiled.Exec(scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+
+	failing := &testFailWriter{limit: 10}
+	if err := compiled.Render(failing, scope); err == nil {
+		t.Errorf("compiled.Render(w, scope) | expect to receive write error")
+	}
+	if _, err := compiled.Exec(scope).WriteTo(failing); err == nil {
+		t.Errorf("rendered.WriteTo(w) | expect to receive write error")
+	}
+}
@@ -0,0 +1,150 @@
+package sht
+
+import (
+	"github.com/antonmedv/expr/checker"
+	"github.com/antonmedv/expr/conf"
+	exprparser "github.com/antonmedv/expr/parser"
+	"github.com/syntax-framework/shtml/cmn"
+	"reflect"
+	"strings"
+)
+
+var errorExpressionEnv = cmn.Err(
+	"expression.env",
+	"The expression is not valid for the environment of the template.",
+	"Expression: %s", "File: %s", "Line: %d", "Column: %d", "Cause: %s",
+)
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// compilerEnvFrame the variables declared by the directives of an element (Ex. the item of the <for>), available to the
+// expressions of the element and its children
+type compilerEnvFrame struct {
+	locals  map[string]reflect.Type
+	isolate bool // the variables of the outer frames and of the env are not visible (Ex. content of the <component>)
+}
+
+// DeclareLocal declares a variable set by the directive being compiled on the scope of the element (Ex. the item of the
+// <for>), used to check the expressions against the env (see TemplateSystem.CompileWithEnv). A nil type accepts any value.
+func (c *Compiler) DeclareLocal(name string, t reflect.Type) {
+	if len(c.envFrames) == 0 {
+		return
+	}
+	frame := c.envFrames[len(c.envFrames)-1]
+	if frame.locals == nil {
+		frame.locals = map[string]reflect.Type{}
+	}
+	if t == nil {
+		t = interfaceType
+	}
+	frame.locals[name] = t
+}
+
+// IsolateEnv the expressions of the element being compiled only have access to the locals declared after this call
+// (Ex. the content of a <component>, which only receives its params)
+func (c *Compiler) IsolateEnv() {
+	if len(c.envFrames) == 0 {
+		return
+	}
+	frame := c.envFrames[len(c.envFrames)-1]
+	frame.isolate = true
+	frame.locals = nil
+}
+
+func (c *Compiler) pushEnvFrame() {
+	c.envFrames = append(c.envFrames, &compilerEnvFrame{})
+}
+
+func (c *Compiler) popEnvFrame() {
+	c.envFrames = c.envFrames[:len(c.envFrames)-1]
+}
+
+// CheckExpression checks the expression against the env of the template (see TemplateSystem.CompileWithEnv), returning
+// its type. Errors are collected and returned at the end of the compilation (see Compiler.Compile).
+//
+// Returns nil when the template has no env or the expression is invalid
+func (c *Compiler) CheckExpression(exp string, node *Node) reflect.Type {
+	if c.env == nil {
+		return nil
+	}
+
+	parts := splitExpression(exp, '|')
+	t, err := c.checkExpression(parts[0])
+	for _, part := range parts[1:] {
+		if err != nil {
+			break
+		}
+		// filter args, the result of the filter is not known
+		t = interfaceType
+		if match := filterRegex.FindStringSubmatch(strings.TrimSpace(part)); match != nil && strings.TrimSpace(match[2]) != "" {
+			for _, arg := range splitExpression(match[2], ',') {
+				if _, err = c.checkExpression(arg); err != nil {
+					break
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		c.errors = append(c.errors, errorExpressionEnv(strings.TrimSpace(exp), node.File, node.Line, node.Column, err.Error()))
+		return nil
+	}
+	return t
+}
+
+// checkInterpolation checks all the expressions of the interpolation
+func (c *Compiler) checkInterpolation(compiled *Compiled, node *Node) {
+	if c.env == nil || compiled == nil {
+		return
+	}
+	for _, dynamic := range compiled.dynamics {
+		switch d := dynamic.(type) {
+		case *DynamicInterpolate:
+			c.CheckExpression(d.expression.source, node)
+		case *DynamicInterpolateEscaped:
+			c.CheckExpression(d.expression.source, node)
+		}
+	}
+}
+
+// checkExpression type checks the expression, the types are the env, the functions and constants of the system and the
+// locals declared by the directives
+func (c *Compiler) checkExpression(exp string) (reflect.Type, error) {
+	tree, err := exprparser.Parse(strings.TrimSpace(exp))
+	if err != nil {
+		return nil, err
+	}
+
+	var locals []*compilerEnvFrame
+	isolated := false
+	for i := len(c.envFrames) - 1; i >= 0; i-- {
+		locals = append(locals, c.envFrames[i])
+		if c.envFrames[i].isolate {
+			isolated = true
+			break
+		}
+	}
+
+	var config *conf.Config
+	if isolated {
+		config = conf.New(nil)
+	} else {
+		config = conf.New(c.env)
+	}
+	if config.Types == nil {
+		config.Types = conf.TypesTable{}
+	}
+	config.Strict = true
+
+	for name, value := range c.System.environment() {
+		config.Types[name] = conf.Tag{Type: reflect.TypeOf(value)}
+	}
+	// the inner frames have priority
+	for i := len(locals) - 1; i >= 0; i-- {
+		for name, t := range locals[i].locals {
+			config.Types[name] = conf.Tag{Type: t}
+		}
+	}
+
+	return checker.Check(tree, config)
+}
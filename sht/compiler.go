@@ -3,10 +3,13 @@ package sht
 import (
 	"bytes"
 	"github.com/syntax-framework/shtml/cmn"
-	"log"
+	"io/fs"
 	"math"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Compiler scope of html template being compiled
@@ -17,6 +20,11 @@ type Compiler struct {
 	Context    *Context            // allows directives to save context information during compilation
 	dynamics   []Dynamic
 	Sequence   *Sequence
+	env        interface{}           // the env of the expressions, see TemplateSystem.CompileWithEnv
+	envFrames  []*compilerEnvFrame   // the locals declared by the directives of the elements being compiled
+	errors     []error               // the errors found in the nodes, reported at once at the end of the compilation
+	files      map[string]time.Time  // the files read by this compilation, see Compiler.Load
+	components map[string]*Component // the components used by the template, by name
 }
 
 // _PrevContext used for previous compilation of the current node
@@ -47,12 +55,21 @@ func NewCompiler(ts *TemplateSystem) *Compiler {
 	}
 }
 
+// Compile compiles the template. The compilation does not stop on the first invalid node, all the errors found in the
+// file are returned at once (cmn.ErrorList)
 func (c *Compiler) Compile(template string, filepath string) (*Compiled, error) {
 	nodeList, err := Parse(template, filepath)
 	if err != nil {
 		return nil, err
 	}
-	return c.compile(nodeList, nil)
+	compiled, err := c.compile(nodeList, nil)
+	if err != nil {
+		c.errors = append(c.errors, err)
+	}
+	if err = cmn.ErrorList(c.errors).Err(); err != nil {
+		return nil, err
+	}
+	return compiled, nil
 }
 
 // NextHash Used by components to predictively obtain a hash
@@ -67,7 +84,7 @@ func (c *Compiler) NextHash() string {
 func (c *Compiler) SafeRemove(node *Node) {
 	node.Type = TextNode
 	node.Data = ""
-	node.Attributes = &Attributes{Map: map[string]*Attribute{}}
+	node.Attributes = NewAttributes()
 	//node.AttrList = []*Attribute{}
 	if node.FirstChild != nil {
 		node.FirstChild.Parent = nil
@@ -101,6 +118,7 @@ func (c *Compiler) RegisterAssetJsURL(src string) (*cmn.Asset, error) {
 
 // RegisterAssetJsFilepath register an existing javascript in the filesystem being used by this template
 func (c *Compiler) RegisterAssetJsFilepath(filepath string) (*cmn.Asset, error) {
+	c.dependOn(filepath)
 	asset, err := c.System.RegisterAssetJsFilepath(filepath)
 	if err != nil {
 		return nil, err
@@ -122,73 +140,79 @@ func (c *Compiler) compileNode(node *Node, context *_PrevContext) (*Compiled, er
 
 // compile compile internal
 func (c *Compiler) compile(nodeList []*Node, context *_PrevContext) (*Compiled, error) {
-	if err := c.processNodes(nodeList, context); err != nil {
-		return nil, err
-	}
-	compiled := c.extractCompiled(nodeList)
-	return compiled, nil
+	c.processNodes(nodeList, context)
+	return c.extractCompiled(nodeList)
 }
 
-// processNodes faz a compilação do nodeList
-func (c *Compiler) processNodes(nodeList []*Node, prevContext *_PrevContext) error {
+// processNodes faz a compilação do nodeList.
+//
+// The errors of a node are collected and the compilation continues on the next node, so that all the problems of the
+// file are reported at once (see Compiler.Compile)
+func (c *Compiler) processNodes(nodeList []*Node, prevContext *_PrevContext) {
 	for _, node := range nodeList {
-		if node.Type == ElementNode {
-			attrs := node.Attributes
+		if err := c.processNode(node, prevContext); err != nil {
+			c.errors = append(c.errors, err)
+		}
+	}
+}
 
-			var err error
-			var dynamic *DynamicDirectives
+// processNode compiles the node and its children
+func (c *Compiler) processNode(node *Node, prevContext *_PrevContext) error {
+	if node.Type == ElementNode {
+		attrs := node.Attributes
 
-			// get the directives that can be applied on that node
-			var toIgnore *Directive
-			if prevContext != nil && prevContext.Ignore != nil && prevContext.Ignore[node] != nil {
-				toIgnore = prevContext.Ignore[node]
-			}
+		var err error
+		var dynamic *DynamicDirectives
 
-			var directives []*Directive
+		// get the directives that can be applied on that node
+		var toIgnore *Directive
+		if prevContext != nil && prevContext.Ignore != nil && prevContext.Ignore[node] != nil {
+			toIgnore = prevContext.Ignore[node]
+		}
 
-			if directives, err = c.Directives.collect(node, attrs, toIgnore); err != nil {
-				return err
-			}
+		var directives []*Directive
 
-			if len(directives) > 0 {
-				dynamic, err = c.compileDirectives(directives, node, attrs, prevContext)
-				if err != nil {
-					return err
-				}
+		if directives, err = c.Directives.collect(node, attrs, toIgnore, c.System); err != nil {
+			return err
+		}
+
+		if len(directives) > 0 {
+			dynamic, err = c.compileDirectives(directives, node, attrs, prevContext)
+			if err != nil {
+				return err
 			}
+		}
 
-			if dynamic != nil && dynamic.transclude {
-				c.replaceNodeByDynamic(node, dynamic)
+		if dynamic != nil && dynamic.transclude {
+			c.replaceNodeByDynamic(node, dynamic)
 
-			} else {
-				if dynamic != nil {
-					// replace attributes
-					_, token := c.addDynamic(dynamic)
-					node.Attributes = &Attributes{Map: map[string]*Attribute{token: {Name: token}}}
-					//node.AttrList = []*Attribute{{Name: token}}
-				}
+		} else {
+			// when a directive replaces the element at compile time (Ex. include), only the new content is processed
+			if dynamic != nil && node.Type == ElementNode {
+				// replace attributes
+				_, token := c.addDynamic(dynamic)
+				node.Attributes = NewAttributes()
+				node.Attributes.Add(&Attribute{Name: token, Normalized: token})
+				//node.AttrList = []*Attribute{{Name: token}}
+			}
 
-				childNodes := node.GetChildNodes()
-				if childNodes != nil || len(childNodes) > 0 {
-					err = c.processNodes(childNodes, prevContext)
-					if err != nil {
-						return err
-					}
-				}
+			childNodes := node.GetChildNodes()
+			if childNodes != nil || len(childNodes) > 0 {
+				c.processNodes(childNodes, prevContext)
 			}
-		} else if node.Type == TextNode {
-			if node.Parent != nil {
-				if node.Parent.Data != "script" && node.Parent.Data != "style" {
-					// ignore script and style
-					if err := c.compileTextNode(node); err != nil {
-						return err
-					}
-				}
-			} else {
+		}
+	} else if node.Type == TextNode {
+		if node.Parent != nil {
+			if node.Parent.Data != "script" && node.Parent.Data != "style" {
+				// ignore script and style
 				if err := c.compileTextNode(node); err != nil {
 					return err
 				}
 			}
+		} else {
+			if err := c.compileTextNode(node); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -202,13 +226,15 @@ var errorTextNodeInterpolation = cmn.Err(
 // compileTextNode verifica se um node do tipo TextNode possui conteúdo dinamico e faz sua compilação
 func (c *Compiler) compileTextNode(node *Node) error {
 	text := node.Data
-	compiled, err := Interpolate(text)
+	compiled, err := c.System.Interpolate(text)
 	if err != nil {
 		if node.Parent != nil {
 			return errorTextNodeInterpolation(node.Parent.DebugTag(), err.Error())
 		}
 		return errorTextNodeInterpolation(node.DebugTag(), err.Error())
 	}
+	c.checkInterpolation(compiled, node)
+	compiled.locate(node)
 
 	// no interpolation found -> ignore
 	if compiled == nil {
@@ -229,8 +255,13 @@ func (c *Compiler) compileTextNode(node *Node) error {
 	return nil
 }
 
+var errorCompilerRender = cmn.Err(
+	"compiler.render",
+	"Error while rendering the compiled nodes.", "Cause: %s",
+)
+
 // faz a renderização do Node e transforma-o em um Compiled
-func (c *Compiler) extractCompiled(nodeList []*Node) *Compiled {
+func (c *Compiler) extractCompiled(nodeList []*Node) (*Compiled, error) {
 
 	var prev *Node
 	root := &Node{Type: DocumentNode}
@@ -247,7 +278,7 @@ func (c *Compiler) extractCompiled(nodeList []*Node) *Compiled {
 
 	htmlStr, err := root.Render()
 	if err != nil {
-		log.Fatal(err)
+		return nil, errorCompilerRender(err.Error())
 	}
 
 	// here it does the second phase of processing, fetches the tokens and generates the final executable
@@ -276,7 +307,7 @@ func (c *Compiler) extractCompiled(nodeList []*Node) *Compiled {
 
 	compiled.static = static
 	compiled.dynamics = dynamics
-	return compiled
+	return compiled, nil
 }
 
 //func assertNoDuplicate(what string, previousDirective *Directive, directive *Directive, element *NodeTest) {
@@ -293,6 +324,10 @@ func (c *Compiler) compileDirectives(directives []*Directive, node *Node, attrs
 
 	terminalPriority := math.MinInt
 
+	// the locals declared by the directives are visible to the element and its children
+	c.pushEnvFrame()
+	defer c.popEnvFrame()
+
 	if prevContext == nil {
 		prevContext = &_PrevContext{}
 	}
@@ -302,9 +337,6 @@ func (c *Compiler) compileDirectives(directives []*Directive, node *Node, attrs
 		tag = node.Data
 	}
 
-	var leaveInfos []*DirectiveLeaveInfo
-	var processInfos []*DirectiveProcessInfo
-
 	dynamic := &DynamicDirectives{
 		tag:   tag,
 		attrs: attrs,
@@ -312,28 +344,80 @@ func (c *Compiler) compileDirectives(directives []*Directive, node *Node, attrs
 
 	//hasTranscludeDirective := false
 
+	// when transclude = "element", the extracted element and the directive that requested it
+	var elementNode *Node
+	var elementDirective *Directive
+
+	// the directive whose template is rendered
+	var templateDirective *Directive
+
+	// the directive that requested an isolate scope
+	var isolateScopeDirective *Directive
+
+	// the lower priority directives were compiled with the element as the content of elementDirective
+	var elementNested bool
+
 	// executes all directives on the current element
-	for _, directive := range directives {
+	for i, directive := range directives {
 		if terminalPriority > directive.Priority {
-			break // prevent further processing of directives
+			if elementNode == nil || elementNested {
+				break // prevent further processing of directives
+			}
+			// the lower priority directives are applied to the transcluded element (Ex. attribute interpolation)
+			if err := c.compileElementDirective(dynamic, elementDirective, directive, elementNode, attrs); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
-		leaveFunc := directive.Leave
-		processFunc := directive.Process
 		transclude := directive.Transclude
+		var slots map[string]*Compiled
+
+		// the Compile may replace the element by new content (Ex. include)
+		replaced := false
 
+		var methods *DirectiveMethods
 		if directive.Compile != nil {
-			methods, err := directive.Compile(node, attrs, c)
-			if err != nil {
+			isElement := node.Type == ElementNode
+			var err error
+			if methods, err = directive.Compile(node, attrs, c); err != nil {
 				return nil, err
 			}
 			if methods != nil {
-				if methods.Process != nil {
-					processFunc = methods.Process
-				}
-				if methods.Leave != nil {
-					leaveFunc = methods.Leave
-				}
+				slots = methods.Slots
+			}
+			replaced = isElement && node.Type != ElementNode
+		}
+
+		if directive.IsolateScope {
+			if isolateScopeDirective != nil {
+				return nil, errorDirectiveScopeMultiple(isolateScopeDirective.Name, directive.Name, node.DebugTag())
+			}
+			isolateScopeDirective = directive
+			dynamic.isolateScope = true
+		}
+		if directive.Scope || directive.IsolateScope {
+			dynamic.scope = true
+		}
+
+		// the template replaces the content of the element, which becomes available through transclusion
+		hasTemplate := (directive.Template != "" || directive.TemplatePath != "") && transclude != "element"
+		if hasTemplate {
+			if dynamic.hasTemplate {
+				return nil, errorDirectiveTemplateMultiple(templateDirective.Name, directive.Name, node.DebugTag())
+			}
+			template, err := c.compileDirectiveTemplate(directive, node)
+			if err != nil {
+				return nil, err
+			}
+			templateDirective = directive
+			dynamic.hasTemplate = true
+			dynamic.template = template
+			dynamic.templateReplace = directive.Replace
+			dynamic.elementStatic = createElementStatic(tag)
+			dynamic.elementFingerprint = HashMD5(strings.Join(*dynamic.elementStatic, ""))
+			if transclude == nil || transclude == false {
+				transclude = true
 			}
 		}
 
@@ -347,13 +431,29 @@ func (c *Compiler) compileDirectives(directives []*Directive, node *Node, attrs
 
 			// see [*DynamicDirectives.createTranscludeFn(scope *Scope, attrs *Attributes)]
 			terminalPriority = directive.Priority
-			contentCompiled, err := c.compileChildNodes(node.ReplaceByText(), prevContext, terminalPriority)
-			if err != nil {
-				return nil, err // @TODO: custom error
+			elementNode = node.ReplaceByText()
+			elementDirective = directive
+
+			if lower := lowerPriorityDirectives(directives[i+1:], directive); hasElementTransclude(lower) {
+				// another directive also transcludes the element, it is compiled with the element as the content of this
+				// directive (Ex. <li for="item in items" if="item.visible">)
+				contentCompiled, err := c.compileNestedElement(lower, elementNode, prevContext)
+				if err != nil {
+					return nil, err
+				}
+				elementNested = true
+				dynamic.transcludeSlots = map[string]*Compiled{"*": contentCompiled}
+			} else {
+				contentCompiled, err := c.compileChildNodes(elementNode, prevContext, terminalPriority)
+				if err != nil {
+					return nil, err // @TODO: custom error
+				}
+				dynamic.transcludeElement = true
+				dynamic.elementStatic = createElementStatic(tag)
+				dynamic.elementFingerprint = HashMD5(strings.Join(*dynamic.elementStatic, ""))
+				dynamic.transcludeSlots = map[string]*Compiled{"*": contentCompiled}
 			}
 			dynamic.transclude = true
-			dynamic.transcludeElement = true
-			dynamic.transcludeSlots = map[string]*Compiled{"*": contentCompiled}
 			transcludeOnThisDirective = true
 
 		} else if transclude == true {
@@ -371,31 +471,34 @@ func (c *Compiler) compileDirectives(directives []*Directive, node *Node, attrs
 
 		} else if config, ok := transclude.(map[string]string); ok {
 
-			println(config)
+			// named slots
+			transcludeSlots, err := c.compileTranscludeSlots(node, directive, config, prevContext, terminalPriority)
+			if err != nil {
+				return nil, err
+			}
+			dynamic.transcludeSlots = transcludeSlots
 			dynamic.transclude = true
 			transcludeOnThisDirective = true
 
 		} else {
-			log.Fatal("@TODO: Invalid transclude!")
+			return nil, errorDirectiveTranscludeInvalid(directive.Name, transclude, node.DebugTag())
 		}
 
-		if processFunc != nil {
-			processInfos = append(processInfos, &DirectiveProcessInfo{
-				name:       directive.Name,
-				callback:   processFunc,
-				terminal:   directive.Terminal,
-				transclude: transcludeOnThisDirective,
-			})
+		if slots != nil {
+			// slots compiled by the directive
+			if dynamic.transcludeSlots == nil {
+				dynamic.transcludeSlots = map[string]*Compiled{}
+			}
+			for name, compiled := range slots {
+				dynamic.transcludeSlots[name] = compiled
+			}
+			dynamic.transclude = true
+			transcludeOnThisDirective = true
 		}
 
-		if leaveFunc != nil {
-			leaveInfos = append(leaveInfos, &DirectiveLeaveInfo{
-				name:     directive.Name,
-				callback: leaveFunc,
-			})
-		}
+		dynamic.addDirective(directive, methods, transcludeOnThisDirective)
 
-		if directive.Terminal {
+		if directive.Terminal || hasTemplate || replaced {
 			dynamic.terminal = true
 			if directive.Priority > terminalPriority {
 				terminalPriority = directive.Priority
@@ -403,20 +506,224 @@ func (c *Compiler) compileDirectives(directives []*Directive, node *Node, attrs
 		}
 	}
 
-	dynamic.leave = leaveInfos
-	dynamic.process = processInfos
+	if dynamic.scope && !dynamic.transclude && node.Type == ElementNode {
+		// the content of the element needs to be rendered with the new scope
+		contentCompiled, err := c.compileChildNodes(node, prevContext, terminalPriority)
+		if err != nil {
+			return nil, err
+		}
+		if contentCompiled != nil {
+			dynamic.transcludeSlots = map[string]*Compiled{"*": contentCompiled}
+		}
+		dynamic.transclude = true
+		dynamic.scopeElement = true
+		dynamic.elementStatic = createElementStatic(tag)
+		dynamic.elementFingerprint = HashMD5(strings.Join(*dynamic.elementStatic, ""))
+	}
 
 	return dynamic, nil
 }
 
+var errorDirectiveTranscludeMultiple = cmn.Err(
+	"directive.transclude.multiple",
+	"Multiple directives asking for transclusion on the same element.", "Directives: [%s, %s]", "Element: %s",
+)
+
+var errorDirectiveTranscludeInvalid = cmn.Err(
+	"directive.transclude.invalid",
+	"Invalid transclude of the directive, expected true, \"element\" or map[string]string (named slots).",
+	"Directive: %s", "Transclude: %v", "Element: %s",
+)
+
+var errorDirectiveScopeMultiple = cmn.Err(
+	"directive.scope.multiple",
+	"Multiple directives asking for an isolate scope on the same element.", "Directives: [%s, %s]", "Element: %s",
+)
+
+var errorDirectiveTemplateMultiple = cmn.Err(
+	"directive.template.multiple",
+	"Multiple directives asking for template on the same element.", "Directives: [%s, %s]", "Element: %s",
+)
+
+var errorDirectiveTemplateLoad = cmn.Err(
+	"directive.template.load",
+	"Could not load the template of the directive.", "Directive: %s", "TemplatePath: %s", "Cause: %s",
+)
+
+// compileDirectiveTemplate parses and compiles the template of the directive. When TemplatePath is informed, the
+// template is loaded from the directive's Assets or, when not informed, by the TemplateSystem
+func (c *Compiler) compileDirectiveTemplate(directive *Directive, node *Node) (*Compiled, error) {
+	template := directive.Template
+	filepath := node.File
+	if directive.TemplatePath != "" {
+		filepath = directive.TemplatePath
+		if directive.Assets != nil {
+			content, err := fs.ReadFile(directive.Assets, directive.TemplatePath)
+			if err != nil {
+				return nil, errorDirectiveTemplateLoad(directive.Name, directive.TemplatePath, err.Error())
+			}
+			template = string(content)
+		} else {
+			content, err := c.Load(directive.TemplatePath)
+			if err != nil {
+				return nil, errorDirectiveTemplateLoad(directive.Name, directive.TemplatePath, err.Error())
+			}
+			template = content
+		}
+	}
+
+	templateNodes, err := Parse(template, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	holder := &Node{Type: DocumentNode}
+	for _, templateNode := range templateNodes {
+		templateNode.PrevSibling = nil
+		templateNode.NextSibling = nil
+		holder.AppendChild(templateNode)
+	}
+	return c.CompileChildren(holder)
+}
+
+var errorDirectiveTranscludeSlotRequired = cmn.Err(
+	"directive.transclude.slot",
+	"Required transclusion slot was not filled.", "Slot: %s", "Expected element: <%s>", "Directive: %s", "Element: %s",
+)
+
+// compileTranscludeSlots when transclude = map[string]string (slot name -> child element name), compiles the content of
+// the matching child elements into separate slots, the remaining content goes to the "*" slot. Elements whose name is
+// prefixed with "?" are optional.
+func (c *Compiler) compileTranscludeSlots(
+	node *Node, directive *Directive, config map[string]string, prevContext *_PrevContext, terminalPriority int,
+) (map[string]*Compiled, error) {
+	slotByElement := map[string]string{}
+	var slotNames []string
+	for slot, element := range config {
+		element = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(element, "?")))
+		slotByElement[element] = slot
+		slotNames = append(slotNames, slot)
+	}
+	sort.Strings(slotNames)
+
+	// the content of each slot
+	holders := map[string]*Node{}
+
+	for _, child := range node.GetChildNodes() {
+		if child.Type != ElementNode {
+			continue
+		}
+		slot, isSlot := slotByElement[child.Data]
+		if !isSlot {
+			continue
+		}
+		holder := holders[slot]
+		if holder == nil {
+			holder = &Node{Type: DocumentNode}
+			holders[slot] = holder
+		}
+		node.RemoveChild(child)
+		content := child.ExtractChildren()
+		for _, contentNode := range content.GetChildNodes() {
+			content.RemoveChild(contentNode)
+			holder.AppendChild(contentNode)
+		}
+	}
+
+	slots := map[string]*Compiled{}
+	for _, slot := range slotNames {
+		element := config[slot]
+		holder := holders[slot]
+		if holder == nil {
+			if !strings.HasPrefix(element, "?") {
+				return nil, errorDirectiveTranscludeSlotRequired(slot, element, directive.Name, node.DebugTag())
+			}
+			continue
+		}
+		compiled, err := c.compileChildNodes(holder, prevContext, terminalPriority)
+		if err != nil {
+			return nil, err
+		}
+		if compiled != nil {
+			slots[slot] = compiled
+		}
+	}
+
+	// remaining content
+	compiled, err := c.compileChildNodes(node, prevContext, terminalPriority)
+	if err != nil {
+		return nil, err
+	}
+	if compiled != nil {
+		slots["*"] = compiled
+	}
+
+	return slots, nil
+}
+
+// compileElementDirective compiles a directive whose priority is lower than the one that transcludes the element
+// (transclude = "element"). Its methods are executed on each rendering of the transcluded element.
+func (c *Compiler) compileElementDirective(
+	dynamic *DynamicDirectives, elementDirective *Directive, directive *Directive, elementNode *Node, attrs *Attributes,
+) error {
+	if directive.Transclude != nil && directive.Transclude != false {
+		return errorDirectiveTranscludeMultiple(elementDirective.Name, directive.Name, elementNode.DebugTag())
+	}
+
+	var methods *DirectiveMethods
+	if directive.Compile != nil {
+		var err error
+		if methods, err = directive.Compile(elementNode, attrs, c); err != nil {
+			return err
+		}
+	}
+
+	dynamic.addElementDirective(directive, methods)
+	return nil
+}
+
+// lowerPriorityDirectives the directives (sorted by priority) that are below the priority of the given directive
+func lowerPriorityDirectives(directives []*Directive, directive *Directive) []*Directive {
+	for i, other := range directives {
+		if other.Priority < directive.Priority {
+			return directives[i:]
+		}
+	}
+	return nil
+}
+
+func hasElementTransclude(directives []*Directive) bool {
+	for _, directive := range directives {
+		if directive.Transclude == "element" {
+			return true
+		}
+	}
+	return false
+}
+
+// compileNestedElement compiles the element with the lower priority directives, the result is the content transcluded
+// by the higher priority directive
+func (c *Compiler) compileNestedElement(directives []*Directive, elementNode *Node, prevContext *_PrevContext) (*Compiled, error) {
+	dynamic, err := c.compileDirectives(directives, elementNode, elementNode.Attributes, prevContext)
+	if err != nil {
+		return nil, err
+	}
+	placeholder := &Node{Type: TextNode}
+	c.replaceNodeByDynamic(placeholder, dynamic)
+	return c.extractCompiled([]*Node{placeholder})
+}
+
+// CompileChildren compiles the child nodes of the given node into a separate Compiled. Used by directives that control
+// the rendering of other parts of the template (Ex. <else>). Returns nil when the node has no content.
+func (c *Compiler) CompileChildren(node *Node) (*Compiled, error) {
+	return c.compileChildNodes(node, nil, math.MinInt)
+}
+
 func (c *Compiler) compileChildNodes(node *Node, prevContext *_PrevContext, terminalPriority int) (*Compiled, error) {
 	childNodes := node.GetChildNodes()
 	if childNodes != nil && len(childNodes) > 0 {
 		// not empty content (not removed by directives)
-		processNodesErr := c.processNodes(childNodes, prevContext)
-		if processNodesErr != nil {
-			return nil, processNodesErr // @TODO: trace
-		}
+		c.processNodes(childNodes, prevContext)
 
 		contentCompiled, err := c.compileNode(node.ExtractChildren(), &_PrevContext{
 			MaxPriority: terminalPriority,
@@ -1,6 +1,8 @@
 package sht
 
 import (
+	"github.com/syntax-framework/shtml/cmn"
+	"strings"
 	"testing"
 )
 
@@ -69,7 +71,7 @@ func Test_transclude_element(t *testing.T) {
 	expected := `
     <div>
       out
-      <div class="xpto" param-1-compile="true" param-2-process="true" param-3="z" disabled empty-attr multiple>
+      <div multiple disabled empty-attr param-3="z" class="xpto" param-1-compile="true" param-2-process="true">
         inner
       </div>
     </div>`
@@ -107,3 +109,153 @@ func Test_transclude_element(t *testing.T) {
 	compiled, _ := TestCompile(t, template, static, directives)
 	TestRender(t, compiled, values, expected)
 }
+
+func Test_transclude_slots(t *testing.T) {
+
+	template := `
+    <div>
+      <panel><panel-title>Title {value}</panel-title>Content<panel-footer>Footer</panel-footer></panel>
+      <panel><panel-title>Only title</panel-title></panel>
+    </div>`
+
+	static := []string{
+		"<div>\n  ",
+		"\n  ",
+		"\n</div>",
+	}
+
+	expected := `
+    <div>
+      <section><h1>Title X</h1>Content<footer>Footer</footer></section>
+      <section><h1>Only title</h1></section>
+    </div>`
+
+	directives := &Directives{}
+	directives.Add(&Directive{
+		Name:       "panel",
+		Restrict:   ELEMENT,
+		Transclude: map[string]string{"title": "panel-title", "footer": "?panel-footer"},
+		Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+			static := []string{"<section><h1>", "</h1>", "", "</section>"}
+			dynamics := []interface{}{transclude("title", nil), transclude("", nil), nil}
+			if footer := transclude("footer", nil); footer != nil {
+				static[2] = "<footer>"
+				static[3] = "</footer></section>"
+				dynamics[2] = footer
+			}
+			return &Rendered{Static: &static, Dynamics: dynamics}
+		},
+	})
+	compiled, _ := TestCompile(t, template, static, directives)
+	TestRender(t, compiled, map[string]interface{}{"value": "X"}, expected)
+
+	// required slot
+	compiler := NewCompiler(&TemplateSystem{Directives: directives.NewChild()})
+	_, err := compiler.Compile(`<panel>Content</panel>`, "template.html")
+	if err == nil || !strings.HasPrefix(err.Error(), "[directive.transclude.slot]") {
+		t.Errorf("compiler.Compile(template) | expect to receive [directive.transclude.slot] error, got: %v", err)
+	}
+}
+
+func Test_directive_scope(t *testing.T) {
+
+	template := `
+    <div>
+      <div counter>{count}</div>
+      <div counter><span>{count}</span></div>
+      {count}
+    </div>`
+
+	expected := `
+    <div>
+      <div counter>1</div>
+      <div counter><span>1</span></div>
+      0
+    </div>`
+
+	var processScope, leaveScope *Scope
+
+	directives := &Directives{}
+	directives.Add(&Directive{
+		Name:     "counter",
+		Restrict: ATTRIBUTE,
+		Scope:    true,
+		Controller: func(scope *Scope) {
+			value, _ := scope.Get("count")
+			scope.SetLocal("count", value.(int)+1)
+		},
+		Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+			processScope = scope
+			return nil
+		},
+		Leave: func(scope *Scope) {
+			leaveScope = scope
+		},
+	})
+	compiled, _ := TestCompile(t, template, nil, directives)
+	_, scope := TestRender(t, compiled, map[string]interface{}{"count": 0}, expected)
+
+	if processScope == nil || processScope == scope || processScope != leaveScope {
+		t.Errorf("DynamicDirectives.Exec(scope) | Process and Leave must receive the same new scope")
+	}
+}
+
+func Test_directive_isolate_scope(t *testing.T) {
+
+	template := `<widget>{outer}</widget>`
+
+	expected := `<widget>[inner-] outer</widget>`
+
+	directives := &Directives{}
+	directives.Add(&Directive{
+		Name:         "widget",
+		Restrict:     ELEMENT,
+		IsolateScope: true,
+		Template:     `[{inner}-{outer}] <slot-content></slot-content>`,
+		Controller: func(scope *Scope) {
+			scope.Set("inner", "inner")
+		},
+	})
+	directives.Add(&Directive{
+		Name:       "slot-content",
+		Restrict:   ELEMENT,
+		Transclude: true,
+		Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+			return scope.Transclude()("", nil)
+		},
+	})
+	compiled, _ := TestCompile(t, template, nil, directives)
+	TestRender(t, compiled, map[string]interface{}{"outer": "outer"}, expected)
+
+	// only one isolate scope per element
+	directives.Add(&Directive{Name: "other", Restrict: ATTRIBUTE, IsolateScope: true})
+	compiler := NewCompiler(&TemplateSystem{Directives: directives.NewChild()})
+	_, err := compiler.Compile(`<widget other></widget>`, "template.html")
+	if err == nil || !strings.HasPrefix(err.Error(), "[directive.scope.multiple]") {
+		t.Errorf("compiler.Compile(template) | expect to receive [directive.scope.multiple] error, got: %v", err)
+	}
+}
+
+func Test_Compile_Errors(t *testing.T) {
+	directives := &Directives{}
+	directives.Add(&Directive{Name: "invalid", Restrict: ELEMENT, Transclude: 1})
+
+	template := "<div>\n  <invalid></invalid>\n  <span>{value +}</span>\n  <b>{value}</b>\n  <i>{(}</i>\n</div>"
+	compiler := NewCompiler(&TemplateSystem{Directives: directives.NewChild()})
+	_, err := compiler.Compile(template, "template.html")
+
+	// all the errors of the file are reported
+	errs, isList := err.(cmn.ErrorList)
+	if !isList {
+		t.Fatalf("compiler.Compile(template) | expect to receive cmn.ErrorList\n   actual: %v", err)
+	}
+	expected := []string{"[directive.transclude.invalid]", "[textNode.interpolation]", "[textNode.interpolation]"}
+	if len(errs) != len(expected) {
+		t.Fatalf("compiler.Compile(template) | invalid number of errors\n   actual: %v", err)
+	}
+	for i, e := range errs {
+		if !strings.HasPrefix(e.Error(), expected[i]) {
+			t.Errorf("compiler.Compile(template) | invalid error\n   actual: %s\n expected: %s", e, expected[i])
+		}
+	}
+}
@@ -1,18 +1,484 @@
 package sht
 
-// Live interface de inter
-type Live struct {
-}
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/syntax-framework/shtml/cmn"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var errorComponentDuplicate = cmn.Err(
+	"component.duplicate",
+	"A component with the same name has already been registered.", "Component: %s", "File: %s", "Previous file: %s",
+)
+
+var errorComponentParamUnknown = cmn.Err(
+	"component.param.unknown",
+	"The component does not declare the parameter.", "Param: %s", "Component: %s", "Element: %s",
+)
+
+var errorComponentParamParse = cmn.Err(
+	"component.param.parse",
+	"Error while parsing the value of the parameter.", "Param: %s", "Value: %s", "Element: %s", "Cause: %s",
+)
+
+var errorComponentParamRequired = cmn.Err(
+	"component.param.required",
+	"The required parameter was not informed.", "Param: %s", "Component: %s", "Element: %s",
+)
+
+var errorComponentParamDefault = cmn.Err(
+	"component.param.default.type",
+	"The default value of the parameter does not match the declared type.",
+	"Param: %s", "Expected: %s", "Default: %s", "Component: %s", "Cause: %s",
+)
+
+var errorComponentParamType = cmn.Err(
+	"component.param.type",
+	"The value of the parameter does not match the declared type.",
+	"Param: %s", "Expected: %s", "Received: %s", "Component: %s", "Element: %s",
+)
+
+var errorComponentEventUnknown = cmn.Err(
+	"component.event.unknown",
+	"The event is not pushed by the template of the component.", "Event: %s", "Component: %s",
+)
+
+var errorComponentEventHandler = cmn.Err(
+	"component.event.handler",
+	"The event handler must be a function that receives the *sht.Scope followed by the arguments of the event, returning nothing or an error.",
+	"Event: %s", "Handler: %s", "Component: %s",
+)
 
-type ComponentFunc func(scope *Scope)
+var errorComponentEventArgs = cmn.Err(
+	"component.event.args",
+	"The number of arguments of the handler does not match the call in the template.",
+	"Event: %s", "Handler: %d", "Template: %d", "Component: %s",
+)
+
+var errorComponentEventPayload = cmn.Err(
+	"component.event.payload",
+	"Invalid payload for the event.", "Event: %s", "Component: %s", "Cause: %s",
+)
 
 // Component a referencia para um componente
+//
+// Declared by <component name="my-card" param-title="string">, used by <my-card param-title="{title}">
 type Component struct {
+	Name     string
+	File     string               // file where the component was declared
+	Params   []cmn.ComponentParam // server params
+	Compiled *Compiled            // the content of the component, nil when empty
+	Assets   []*cmn.Asset         // the resources used by the component
+	Events   map[string]int       // the events pushed by the template (onclick="increment(count)") and their number of arguments
+	defaults map[string]interface{}
+	handlers map[string]reflect.Value
+	system   *TemplateSystem // the system where the component was registered, resolves the filters of the params
 }
 
-/*
-CreateComponent cria um componente. Um component é uma estrutura reutilizável que possui características especiais
-*/
-func CreateComponent(node *Node, attrs *Attributes, t *Compiler) {
+var scopeType = reflect.TypeOf((*Scope)(nil))
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Handle registers the Go handler of an event pushed by the template of the component. The handler receives the scope
+// of the component followed by the arguments of the call in the template, which are decoded from the payload.
+//
+// Ex. onclick="increment(count, 2)" -> component.Handle("increment", func(scope *sht.Scope, count int, step int) error)
+func (c *Component) Handle(event string, handler interface{}) error {
+	args, declared := c.Events[event]
+	if !declared {
+		return errorComponentEventUnknown(event, c.Name)
+	}
+
+	fn := reflect.ValueOf(handler)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func || fnType.NumIn() == 0 || fnType.In(0) != scopeType || fnType.IsVariadic() ||
+		fnType.NumOut() > 1 || (fnType.NumOut() == 1 && fnType.Out(0) != errorType) {
+		return errorComponentEventHandler(event, fnType.String(), c.Name)
+	}
+
+	if fnType.NumIn()-1 != args {
+		return errorComponentEventArgs(event, fnType.NumIn()-1, args, c.Name)
+	}
+
+	if c.handlers == nil {
+		c.handlers = map[string]reflect.Value{}
+	}
+	c.handlers[event] = fn
+	return nil
+}
+
+// Dispatch invokes the handler of the event, converting the values of the payload (decoded from JSON) to the types of
+// the arguments of the handler
+func (c *Component) Dispatch(scope *Scope, event string, payload []interface{}) error {
+	fn, exists := c.handlers[event]
+	if !exists {
+		return errorComponentEventUnknown(event, c.Name)
+	}
+
+	fnType := fn.Type()
+	if len(payload) != fnType.NumIn()-1 {
+		return errorComponentEventPayload(
+			event, c.Name, fmt.Sprintf("expected %d arguments, received %d", fnType.NumIn()-1, len(payload)),
+		)
+	}
+
+	in := []reflect.Value{reflect.ValueOf(scope)}
+	for i, value := range payload {
+		arg := reflect.New(fnType.In(i + 1))
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(data, arg.Interface())
+		}
+		if err != nil {
+			return errorComponentEventPayload(event, c.Name, fmt.Sprintf("argument %d: %s", i, err.Error()))
+		}
+		in = append(in, arg.Elem())
+	}
+
+	out := fn.Call(in)
+	if len(out) == 1 && !out[0].IsNil() {
+		return out[0].Interface().(error)
+	}
+	return nil
+}
+
+// compileDefaults evaluates the default values of the parameters (Ex. param-size="?number = 12")
+func (c *Component) compileDefaults(s *TemplateSystem) error {
+	c.defaults = map[string]interface{}{}
+	for i := range c.Params {
+		param := &c.Params[i]
+		if !param.HasDefault {
+			continue
+		}
+		if err := s.ParamDefault(c.Name, param); err != nil {
+			return err
+		}
+		c.defaults[param.Name] = param.DefaultValue
+	}
+	return nil
+}
+
+// ParamDefault evaluates the default value of the parameter of the component, converted to the declared type
+// (cmn.ComponentParam.DefaultValue)
+func (s *TemplateSystem) ParamDefault(component string, param *cmn.ComponentParam) error {
+	name := "param-" + strcase.ToKebab(param.Name)
+	if param.IsClient {
+		name = "client-" + name
+	}
+
+	expression, err := s.ParseExpression(param.Default)
+	if err != nil {
+		return errorComponentParamDefault(name, param.TypeName, param.Default, component, err.Error())
+	}
+	value, err := expression.run(NewRootScope())
+	if err != nil {
+		return errorComponentParamDefault(name, param.TypeName, param.Default, component, err.Error())
+	}
+	coerced, valid := coerceComponentParam(param, value)
+	if !valid {
+		return errorComponentParamDefault(name, param.TypeName, param.Default, component, fmt.Sprintf("%T", value))
+	}
+	param.DefaultValue = coerced
+	return nil
+}
+
+// param get a parameter by name
+func (c *Component) param(name string) *cmn.ComponentParam {
+	for i := range c.Params {
+		if c.Params[i].Name == name {
+			return &c.Params[i]
+		}
+	}
+	return nil
+}
+
+// componentParamValue gets the value of a parameter from the scope of the caller
+type componentParamValue func(scope *Scope) interface{}
+
+// coerceComponentParam converts the value to the declared type of the parameter, returns false when the value is not
+// compatible. Numbers are always converted to float64
+func coerceComponentParam(param *cmn.ComponentParam, value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, true
+	}
+
+	switch param.Type {
+	case cmn.ParamTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			return b, err == nil
+		}
+		return nil, false
+
+	case cmn.ParamTypeNumber:
+		if s, isString := value.(string); isString {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			return n, err == nil
+		}
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return float64(v.Uint()), true
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), true
+		}
+		return nil, false
+
+	case cmn.ParamTypeString:
+		if s, isString := value.(string); isString {
+			return s, true
+		}
+		return fmt.Sprintf("%v", value), true
+
+	case cmn.ParamTypeArray:
+		kind := reflect.ValueOf(value).Kind()
+		return value, kind == reflect.Slice || kind == reflect.Array
+
+	case cmn.ParamTypeObject:
+		v := reflect.ValueOf(value)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		return value, v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+
+	case cmn.ParamTypeFunc:
+		return value, reflect.ValueOf(value).Kind() == reflect.Func
+	}
+
+	return value, true
+}
+
+// createComponentParamValue the value of the parameter is an interpolation. When the value is a single expression
+// (Ex. param-items="{items}"), the result of the expression is used without conversion to string
+func createComponentParamValue(s *TemplateSystem, value string) (componentParamValue, error) {
+	interpolation, err := s.Interpolate(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if interpolation == nil {
+		return func(scope *Scope) interface{} { return value }, nil
+	}
+
+	if len(interpolation.static) == 2 && interpolation.static[0] == "" && interpolation.static[1] == "" {
+		var expression *Expression
+		switch dynamic := interpolation.dynamics[0].(type) {
+		case *DynamicInterpolate:
+			expression = dynamic.expression
+		case *DynamicInterpolateEscaped:
+			expression = dynamic.expression
+		}
+		if expression != nil {
+			return expression.Exec, nil
+		}
+	}
+
+	return func(scope *Scope) interface{} {
+		return interpolation.Exec(scope).String()
+	}, nil
+}
+
+// createComponentParamCoercion validates the value of the parameter on each rendering. Invalid values are not passed to
+// the component, when the scope is strict the error is returned by Compiled.Execute (see Scope.SetStrict)
+func createComponentParamCoercion(
+	c *Component, param *cmn.ComponentParam, attrName string, value componentParamValue, callSite string,
+) componentParamValue {
+	return func(scope *Scope) interface{} {
+		raw := value(scope)
+		coerced, valid := coerceComponentParam(param, raw)
+		if !valid {
+			err := errorComponentParamType(attrName, param.TypeName, fmt.Sprintf("%T", raw), c.Name, callSite)
+			scope.ReportError(err)
+			return nil
+		}
+		return coerced
+	}
+}
+
+// compileUsage compiles the usage of the component (<my-card param-title="{title}">)
+func (c *Component) compileUsage(node *Node, attrs *Attributes, compiler *Compiler) (*DirectiveMethods, error) {
+	callSite := node.DebugTag()
+
+	values := map[string]interface{}{}
+	for _, attr := range attrs.List() {
+		if name := attr.Normalized; strings.HasPrefix(name, "param-") {
+			values[name] = attr.Value
+			if interpolation, err := compiler.System.Interpolate(attr.Value); err == nil {
+				compiler.checkInterpolation(interpolation, node)
+			}
+		}
+	}
+
+	methods, err := c.createUsage(values, callSite)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, param := range c.Params {
+		if _, informed := values["param-"+strcase.ToKebab(param.Name)]; param.Required && !informed {
+			return nil, errorComponentParamRequired("param-"+strcase.ToKebab(param.Name), c.Name, callSite)
+		}
+	}
+
+	// the children with the slot attribute (<div slot="header">) are available to the component by name, the remaining
+	// content is the default slot
+	holders := map[string]*Node{}
+	for _, child := range node.GetChildNodes() {
+		if child.Type != ElementNode {
+			continue
+		}
+		slotAttr := child.Attributes.GetAttribute("slot")
+		if slotAttr == nil {
+			continue
+		}
+		child.Attributes.Remove(slotAttr)
+		holder := holders[slotAttr.Value]
+		if holder == nil {
+			holder = &Node{Type: DocumentNode}
+			holders[slotAttr.Value] = holder
+		}
+		node.RemoveChild(child)
+		holder.AppendChild(child)
+	}
+
+	methods.Slots = map[string]*Compiled{}
+	for slot, holder := range holders {
+		compiled, err := compiler.CompileChildren(holder)
+		if err != nil {
+			return nil, err
+		}
+		if compiled != nil {
+			methods.Slots[slot] = compiled
+		}
+	}
+
+	for _, asset := range c.Assets {
+		compiler.RegisterAsset(asset)
+	}
+
+	return methods, nil
+}
+
+// restoreComponentUsage see Directive.Restore
+func restoreComponentUsage(config map[string]interface{}, s *TemplateSystem) (*DirectiveMethods, error) {
+	component, _ := config["component"].(*Component)
+	if component == nil {
+		return nil, errorCompiledDecode("the component of the usage was not found")
+	}
+	values, _ := config["params"].(map[string]interface{})
+	callSite, _ := config["element"].(string)
+	return component.createUsage(values, callSite)
+}
+
+// createUsage creates the methods of the usage of the component, values are the param-* attributes of the element
+func (c *Component) createUsage(values map[string]interface{}, callSite string) (*DirectiveMethods, error) {
+	params := map[string]componentParamValue{}
+	for name, attrValue := range values {
+		paramName := strcase.ToLowerCamel(strings.TrimPrefix(name, "param-"))
+		param := c.param(paramName)
+		if param == nil {
+			return nil, errorComponentParamUnknown(name, c.Name, callSite)
+		}
+
+		text, _ := attrValue.(string)
+		if !strings.ContainsRune(text, '{') {
+			// literal, validated at compile time
+			literal, valid := coerceComponentParam(param, text)
+			if !valid {
+				return nil, errorComponentParamType(name, param.TypeName, text, c.Name, callSite)
+			}
+			params[paramName] = func(scope *Scope) interface{} { return literal }
+			continue
+		}
+
+		value, err := createComponentParamValue(c.system, text)
+		if err != nil {
+			return nil, errorComponentParamParse(name, text, callSite, err.Error())
+		}
+		params[paramName] = createComponentParamCoercion(c, param, name, value, callSite)
+	}
+
+	return &DirectiveMethods{
+		Config: map[string]interface{}{"component": c, "params": values, "element": callSite},
+		Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+			if c.Compiled == nil {
+				return nil
+			}
+
+			// in a LiveSession the scope of the instance is kept between renders, the defaults are only applied once so
+			// that the changes made by the event handlers are not lost
+			componentScope, created := scope.instanceScope(c.Name)
+			if created {
+				for name, value := range c.defaults {
+					componentScope.SetLocal(name, value)
+				}
+			}
+			for name, value := range params {
+				if paramValue := value(scope); paramValue != nil {
+					componentScope.SetLocal(name, paramValue)
+				}
+			}
+			componentScope.transclude = transclude
+
+			return c.Compiled.Exec(componentScope)
+		},
+	}, nil
+}
+
+// RegisterComponent register a component, allowing its use by other templates (<my-card></my-card>)
+//
+// Recompiling the file that declares the component replaces the previous declaration
+func (s *TemplateSystem) RegisterComponent(component *Component) error {
+	name := NormalizeName(component.Name)
+
+	if err := component.compileDefaults(s); err != nil {
+		return err
+	}
+	component.system = s
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Components == nil {
+		s.Components = map[string]*Component{}
+	}
+
+	if previous, exists := s.Components[name]; exists {
+		if previous.File != component.File {
+			return errorComponentDuplicate(name, component.File, previous.File)
+		}
+		s.Components[name] = component
+		return nil
+	}
+
+	s.Components[name] = component
+	s.Directives.Add(&Directive{
+		Name:       name,
+		Restrict:   ELEMENT,
+		Priority:   1000,
+		Terminal:   true,
+		Transclude: true,
+		Compile: func(node *Node, attrs *Attributes, c *Compiler) (*DirectiveMethods, error) {
+			s.mu.RLock()
+			component := s.Components[name]
+			s.mu.RUnlock()
+			c.useComponent(name, component)
+			return component.compileUsage(node, attrs, c)
+		},
+		Restore: restoreComponentUsage,
+	})
+	return nil
+}
 
+// RegisterComponent register a component declared in the template being compiled
+func (c *Compiler) RegisterComponent(component *Component) error {
+	return c.System.RegisterComponent(component)
 }
@@ -0,0 +1,236 @@
+package sht
+
+// RenderedDiff the changes between two renders of a Compiled, allows to update a client that already has the previous
+// Rendered (see Diff and Apply)
+//
+// When the fingerprints match, the statics are omitted and Dynamics only has the changed indexes. Each changed dynamic is
+// nil, a string or a *RenderedDiff of the nested Rendered.
+type RenderedDiff struct {
+	Static      *[]string           `json:"s,omitempty"` // only when the fingerprint changed
+	Fingerprint string              `json:"f,omitempty"`
+	Root        bool                `json:"r,omitempty"`
+	Dynamics    map[int]interface{} `json:"d,omitempty"` // changed dynamics by index
+	Assets      *[]string           `json:"a,omitempty"` // when changed, empty when all the assets were removed
+	List        bool                `json:"l,omitempty"` // the Rendered is a list (Static == nil), Ex. <for>
+	Length      int                 `json:"n,omitempty"` // when list, the number of items
+	Keys        []string            `json:"k,omitempty"` // when keyed list, the key of each item
+}
+
+// Diff computes the changes needed to transform prev into next, nil when nothing changed.
+//
+// Items of keyed lists (Rendered.Keys) are compared with the previous item with the same key, so reordering a list
+// only sends the new order of the keys.
+func Diff(prev *Rendered, next *Rendered) *RenderedDiff {
+	if next == nil {
+		return nil
+	}
+
+	if prev == nil || (next.Static == nil) != (prev.Static == nil) ||
+		(next.Static != nil && prev.Fingerprint != next.Fingerprint) {
+		return fullDiff(next)
+	}
+
+	diff := &RenderedDiff{}
+	if !equalStrings(prev.Assets, next.Assets) {
+		assets := append([]string{}, next.Assets...)
+		diff.Assets = &assets
+	}
+
+	if next.Static == nil {
+		diff.List = true
+		diff.Length = len(next.Dynamics)
+
+		changed := len(prev.Dynamics) != len(next.Dynamics) || !equalStrings(prev.Keys, next.Keys)
+		if next.Keys != nil {
+			diff.Keys = next.Keys
+		}
+
+		previous := listItemsByKey(prev)
+		for i, item := range next.Dynamics {
+			var base interface{}
+			if next.Keys != nil {
+				if i < len(next.Keys) {
+					base = previous[next.Keys[i]]
+				}
+			} else if i < len(prev.Dynamics) {
+				base = prev.Dynamics[i]
+			}
+			if change, hasChange := diffDynamic(base, item); hasChange {
+				diff.addDynamic(i, change)
+			}
+		}
+
+		if !changed && diff.Dynamics == nil && diff.Assets == nil {
+			return nil
+		}
+		return diff
+	}
+
+	for i, item := range next.Dynamics {
+		var base interface{}
+		if i < len(prev.Dynamics) {
+			base = prev.Dynamics[i]
+		}
+		if change, hasChange := diffDynamic(base, item); hasChange {
+			diff.addDynamic(i, change)
+		}
+	}
+
+	if diff.Dynamics == nil && diff.Assets == nil {
+		return nil
+	}
+	return diff
+}
+
+func (d *RenderedDiff) addDynamic(index int, value interface{}) {
+	if d.Dynamics == nil {
+		d.Dynamics = map[int]interface{}{}
+	}
+	d.Dynamics[index] = value
+}
+
+// fullDiff all the content of the Rendered
+func fullDiff(r *Rendered) *RenderedDiff {
+	diff := &RenderedDiff{
+		Static:      r.Static,
+		Fingerprint: r.Fingerprint,
+		Root:        r.Root,
+	}
+	if r.Assets != nil {
+		diff.Assets = &r.Assets
+	}
+	if r.Static == nil {
+		diff.List = true
+		diff.Length = len(r.Dynamics)
+		diff.Keys = r.Keys
+	}
+	for i, item := range r.Dynamics {
+		if change, hasChange := diffDynamic(nil, item); hasChange {
+			diff.addDynamic(i, change)
+		}
+	}
+	return diff
+}
+
+// diffDynamic the change of a dynamic (nil, string, *RenderedDiff)
+func diffDynamic(prev interface{}, next interface{}) (interface{}, bool) {
+	nextRendered, nextIsRendered := next.(*Rendered)
+	if nextIsRendered && nextRendered == nil {
+		next, nextIsRendered = nil, false
+	}
+	prevRendered, prevIsRendered := prev.(*Rendered)
+	if prevIsRendered && prevRendered == nil {
+		prev, prevIsRendered = nil, false
+	}
+
+	if nextIsRendered {
+		if !prevIsRendered {
+			return fullDiff(nextRendered), true
+		}
+		if diff := Diff(prevRendered, nextRendered); diff != nil {
+			return diff, true
+		}
+		return nil, false
+	}
+
+	if prevIsRendered {
+		return next, true
+	}
+	return next, prev != next
+}
+
+// listItemsByKey the items of a keyed list by key
+func listItemsByKey(r *Rendered) map[string]interface{} {
+	items := map[string]interface{}{}
+	for i, key := range r.Keys {
+		if _, exists := items[key]; !exists && i < len(r.Dynamics) {
+			items[key] = r.Dynamics[i]
+		}
+	}
+	return items
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply applies the changes computed by Diff to prev, returning the new Rendered. prev is not modified.
+func Apply(prev *Rendered, diff *RenderedDiff) *Rendered {
+	if diff == nil {
+		return prev
+	}
+
+	next := &Rendered{}
+	if diff.Assets != nil {
+		next.Assets = *diff.Assets
+	}
+	if diff.Static != nil || prev == nil {
+		next.Static = diff.Static
+		next.Fingerprint = diff.Fingerprint
+		next.Root = diff.Root
+		prev = nil // full
+	} else {
+		next.Static = prev.Static
+		next.Fingerprint = prev.Fingerprint
+		next.Root = prev.Root
+		if diff.Assets == nil {
+			next.Assets = prev.Assets
+		}
+	}
+
+	if diff.List {
+		next.Static = nil
+		next.Keys = diff.Keys
+		next.Dynamics = make([]interface{}, diff.Length)
+
+		var previous map[string]interface{}
+		if prev != nil && diff.Keys != nil {
+			previous = listItemsByKey(prev)
+		}
+		for i := range next.Dynamics {
+			var base interface{}
+			if previous != nil {
+				if i < len(diff.Keys) {
+					base = previous[diff.Keys[i]]
+				}
+			} else if prev != nil && i < len(prev.Dynamics) {
+				base = prev.Dynamics[i]
+			}
+			next.Dynamics[i] = applyDynamic(base, diff, i)
+		}
+		return next
+	}
+
+	if next.Static != nil && len(*next.Static) > 0 {
+		next.Dynamics = make([]interface{}, len(*next.Static)-1)
+	}
+	for i := range next.Dynamics {
+		var base interface{}
+		if prev != nil && i < len(prev.Dynamics) {
+			base = prev.Dynamics[i]
+		}
+		next.Dynamics[i] = applyDynamic(base, diff, i)
+	}
+	return next
+}
+
+// applyDynamic the dynamic at index, unchanged when not informed in the diff
+func applyDynamic(base interface{}, diff *RenderedDiff, index int) interface{} {
+	change, changed := diff.Dynamics[index]
+	if !changed {
+		return base
+	}
+	if changeDiff, isDiff := change.(*RenderedDiff); isDiff {
+		baseRendered, _ := base.(*Rendered)
+		return Apply(baseRendered, changeDiff)
+	}
+	return change
+}
@@ -0,0 +1,121 @@
+package sht
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// testDiffApply checks if applying the diff to prev results in next
+func testDiffApply(t *testing.T, prev *Rendered, next *Rendered) *RenderedDiff {
+	diff := Diff(prev, next)
+	if actual, expected := Apply(prev, diff).String(), next.String(); actual != expected {
+		t.Errorf("Apply(prev, Diff(prev, next)) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+	return diff
+}
+
+func Test_Diff(t *testing.T) {
+	template := `<div class="{a}"><span>{b}</span>!{c}</div>`
+	compiled, _ := TestCompile(t, template, nil, &Directives{})
+
+	render := func(a string, b string, c string) *Rendered {
+		scope := NewRootScope()
+		scope.Set("a", a)
+		scope.Set("b", b)
+		scope.Set("c", c)
+		return compiled.Exec(scope)
+	}
+
+	first := render("x", "y", "z")
+	second := render("x", "changed", "z")
+
+	full := testDiffApply(t, nil, first)
+	if full.Static == nil || len(full.Dynamics) != len(first.Dynamics) {
+		t.Errorf("Diff(nil, next) | expect full diff, actual: %+v", full)
+	}
+
+	diff := testDiffApply(t, first, second)
+	if diff == nil || diff.Static != nil || len(diff.Dynamics) != 1 || diff.Dynamics[1] != "changed" {
+		t.Errorf("Diff(prev, next) | expect only the changed dynamic, actual: %+v", diff)
+	}
+
+	if diff = Diff(second, render("x", "changed", "z")); diff != nil {
+		t.Errorf("Diff(prev, next) | expect nil when nothing changed, actual: %+v", diff)
+	}
+
+	data, err := json.Marshal(testDiffApply(t, second, render("x", "y", "<b>")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := string(data), `{"d":{"1":"y","2":"\u003cb\u003e"}}`; actual != expected {
+		t.Errorf("json.Marshal(diff) | invalid output\n   actual: %s\n expected: %s", actual, expected)
+	}
+}
+
+func Test_Diff_List(t *testing.T) {
+	static := &[]string{"<li>", "</li>"}
+	item := func(value string) *Rendered {
+		return &Rendered{Static: static, Fingerprint: "li", Dynamics: []interface{}{value}}
+	}
+	list := func(keys []string, values ...string) *Rendered {
+		rendered := &Rendered{Keys: keys}
+		for _, value := range values {
+			rendered.Dynamics = append(rendered.Dynamics, item(value))
+		}
+		return &Rendered{Static: &[]string{"<ul>", "</ul>"}, Fingerprint: "ul", Dynamics: []interface{}{rendered}}
+	}
+
+	prev := list([]string{"1", "2", "3"}, "A", "B", "C")
+
+	// reorder, only the keys are sent
+	diff := testDiffApply(t, prev, list([]string{"3", "1", "2"}, "C", "A", "B"))
+	items := diff.Dynamics[0].(*RenderedDiff)
+	if !items.List || items.Length != 3 || len(items.Dynamics) != 0 {
+		t.Errorf("Diff(prev, next) | expect only the keys of the reordered list, actual: %+v", items)
+	}
+
+	// removed, added and changed items
+	diff = testDiffApply(t, prev, list([]string{"2", "4", "1"}, "B", "D", "A2"))
+	items = diff.Dynamics[0].(*RenderedDiff)
+	if len(items.Dynamics) != 2 || items.Dynamics[1].(*RenderedDiff).Static == nil || items.Dynamics[2].(*RenderedDiff).Static != nil {
+		t.Errorf("Diff(prev, next) | expect the new item and the changed item, actual: %+v", items)
+	}
+
+	// positional list
+	testDiffApply(t, list(nil, "A", "B"), list(nil, "A", "C", "D"))
+	testDiffApply(t, list(nil, "A", "B"), list(nil))
+}
+
+func Test_Diff_Assets(t *testing.T) {
+	static := &[]string{"<div>", "</div>"}
+	render := func(assets ...string) *Rendered {
+		return &Rendered{Static: static, Fingerprint: "div", Dynamics: []interface{}{"x"}, Assets: assets}
+	}
+
+	prev := render("a", "b")
+
+	if diff := Diff(prev, render("a", "b")); diff != nil {
+		t.Errorf("Diff(prev, next) | expect nil when the assets did not change, actual: %+v", diff)
+	}
+
+	changed := Diff(prev, render("a", "c"))
+	if actual := Apply(prev, changed).Assets; len(actual) != 2 || actual[1] != "c" {
+		t.Errorf("Apply(prev, diff) | invalid assets\n   actual: %v\n expected: [a c]", actual)
+	}
+
+	// all the assets were removed
+	removed := Diff(prev, render())
+	if removed == nil || removed.Assets == nil || len(*removed.Assets) != 0 {
+		t.Fatalf("Diff(prev, next) | expect the removal of the assets, actual: %+v", removed)
+	}
+	if actual := Apply(prev, removed).Assets; len(actual) != 0 {
+		t.Errorf("Apply(prev, diff) | expect no assets\n   actual: %v", actual)
+	}
+	data, err := json.Marshal(removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := string(data), `{"a":[]}`; actual != expected {
+		t.Errorf("json.Marshal(diff) | invalid output\n   actual: %s\n expected: %s", actual, expected)
+	}
+}
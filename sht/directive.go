@@ -20,6 +20,10 @@ type DirectiveProcessFunc func(scope *Scope, attr *Attributes, transclude Transc
 // DirectiveLeaveFunc função executada após a renderização do elemento associado a uma diretiva
 type DirectiveLeaveFunc func(scope *Scope)
 
+// DirectiveRestoreFunc recreates the methods of the directive from the DirectiveMethods.Config, used when decoding a
+// Compiled (see Compiled.Encode). The system is the one decoding the Compiled, resolves the expressions.
+type DirectiveRestoreFunc func(config map[string]interface{}, system *TemplateSystem) (*DirectiveMethods, error)
+
 // DirectiveCompileFunc uma funcão que visita um elemento html e pode realizar ajustes no template em tempo de compilação
 type DirectiveCompileFunc func(node *Node, attrs *Attributes, c *Compiler) (*DirectiveMethods, error)
 
@@ -28,10 +32,16 @@ type DirectiveProcessInfo struct {
 	require    string
 	isolate    bool // isolate scope
 	terminal   bool // indica que é a função terminal que será executada
+	stream     bool // see Directive.Stream
 	callback   DirectiveProcessFunc
 	transclude bool // quando true, o parametro transclude será criado para essa execuçao
 }
 
+type DirectiveControllerInfo struct {
+	name     string
+	callback DirectiveControllerFunc
+}
+
 type DirectiveLeaveInfo struct {
 	name     string
 	callback DirectiveLeaveFunc
@@ -49,6 +59,11 @@ type DirectiveMethods struct {
 	Controller DirectiveControllerFunc
 	Process    DirectiveProcessFunc
 	Leave      DirectiveLeaveFunc
+	// Slots content compiled by the directive itself, available to the transclude function by name (Ex. <switch>)
+	Slots map[string]*Compiled
+	// Config the result of the compilation, encoded with the Compiled and passed to Directive.Restore when decoding.
+	// Accepts nil, bool, string, float64, *Compiled, *Component, []interface{} and map[string]interface{}
+	Config map[string]interface{}
 }
 
 // Directive @TODO: Salvar a referencia de todas as diretivas cadastradas, não permitir que a mesma diretiva seja redefinida ou
@@ -61,9 +76,13 @@ type Directive struct {
 	// get called. Directive with greater numerical priority are compiled first. The default priority is 0.
 	Priority int
 	Restrict DirectiveRestrict
-	// Quando possuir template, a diretiva é Terminal
+	// Quando possuir template, a diretiva é Terminal. The original children of the element are available to the template
+	// through transclusion (<transclude slot=""/>)
 	Template     string
-	TemplatePath string
+	TemplatePath string // loaded from Assets, when informed, or by the TemplateSystem
+	// Replace false (default): the template is the content of the directive's element. true: the template replaces the
+	// directive's element
+	Replace bool
 	// Assets e acesso ao fs.Sys, um diretório para permitir carregamento em tempo de compilação
 	Assets fs.FS
 	// true - transclude the transcludeSlots (i.e. the child nodes) of the directive's element.
@@ -71,6 +90,10 @@ type Directive struct {
 	// defined at a lower priority than this directive. When used, the template property is ignored.
 	// {...} (an object hash): - map elements of the transcludeSlots onto transclusion "slots" in the template.
 	Transclude interface{} // true, false, map[string]string
+	// Stream the Process does not change the result of the transclude function (Ex. <for>, <if>). When rendering to an
+	// io.Writer (Compiled.Render), the transcluded content is written as it is rendered, without building its Rendered,
+	// and the transclude function returns nil
+	Stream bool
 	// If set to true then the current priority will be the last set of Directives which will execute (any Directive at
 	// the current priority will still execute as the order of execution on same priority is undefined).
 	// Note that expressions and other Directive used in the directive's template will also be excluded from execution.
@@ -81,20 +104,19 @@ type Directive struct {
 	// false (default): No scope will be created for the directive. The directive will use its root's scope.
 	// true: A new child scope that prototypically inherits from its root will be created for the directive's element.
 	// If multiple Directives on the same element request a new scope, only one new scope is created.
-	Scope      bool
-	Compile    DirectiveCompileFunc
+	Scope bool
+	// IsolateScope the new scope does not inherit from its root (implies Scope). The transcluded content keeps using the
+	// root scope. Only one directive per element can request an isolate scope.
+	IsolateScope bool
+	Compile      DirectiveCompileFunc
+	// Controller executed before the Process of all directives of the element, allows initializing the scope
 	Controller DirectiveControllerFunc
 	Process    DirectiveProcessFunc
 	Leave      DirectiveLeaveFunc
+	// Restore required when Compile returns methods, recreates them from DirectiveMethods.Config when decoding a Compiled
+	Restore DirectiveRestoreFunc
 }
 
-// @TODO: serializable
-// DIRECTIVE{
-//  Compile func(node *Node, attrs *Attributes, c *Compiler) (apply bool, config map[string]interface{}, err error)
-//  Process func(scope *Scope, attr *Attributes, config map[string]interface{}, transclude TranscludeFunc) (rendered *Rendered)
-//  Leave func(scope *Scope, config map[string]interface{})
-//}
-
 func (d *Directive) Normalize() {
 	d.Name = strings.ToLower(strings.TrimSpace(d.Name))
 	if d.Priority < 0 {
@@ -104,10 +126,12 @@ func (d *Directive) Normalize() {
 
 // DynamicDirectives parte dinamica que executa as diretivas de um Node
 type DynamicDirectives struct {
-	tag      string
-	attrs    *Attributes // template attrs
-	scope    bool
-	terminal bool
+	tag          string
+	attrs        *Attributes // template attrs
+	scope        bool        // some directive requested a new scope
+	isolateScope bool        // the new scope is isolated
+	scopeElement bool        // the element is rendered by the directives, so its content can use the new scope
+	terminal     bool
 	//templateOnThisElement    bool
 	//newScopeDirective        bool
 	//newIsolateScopeDirective bool
@@ -115,11 +139,127 @@ type DynamicDirectives struct {
 	//transcludeFn             *_TranscludeFn
 	//isComposite              bool
 	//composite                []*_RenderComposite
+	controller        []*DirectiveControllerInfo
 	process           []*DirectiveProcessInfo
 	leave             []*DirectiveLeaveInfo
 	transclude        bool                 // alguma directiva possui transclude
 	transcludeElement bool                 // o tipo de transclude é "element"
 	transcludeSlots   map[string]*Compiled // os slots usados para transclude
+	// when transclude = "element" or the directive has a template
+	elementStatic      *[]string               // static parts of the element rendered by the directive
+	elementFingerprint string                  // fingerprint of elementStatic
+	elementProcess     []*DirectiveProcessInfo // lower priority directives, executed on each rendering of the element
+	elementLeave       []*DirectiveLeaveInfo
+	directives         []*directiveRef // the directives applied to the element, in order of execution
+	elementDirectives  []*directiveRef // the directives applied to the transcluded element
+	hasTemplate        bool            // the directive has a template
+	template           *Compiled       // nil when empty
+	templateReplace    bool            // the template replaces the element
+}
+
+// directiveRef a directive applied to an element and the result of its compilation
+type directiveRef struct {
+	directive  *Directive
+	methods    *DirectiveMethods // result of Directive.Compile
+	transclude bool              // the transclude function is available to the Process method
+}
+
+// addDirective adds the methods of the directive to the execution of the element. The methods returned by
+// Directive.Compile take precedence over the methods declared in the Directive
+func (nd *DynamicDirectives) addDirective(directive *Directive, methods *DirectiveMethods, transclude bool) {
+	nd.directives = append(nd.directives, &directiveRef{directive: directive, methods: methods, transclude: transclude})
+
+	controller, process, leave := directive.Controller, directive.Process, directive.Leave
+	if methods != nil {
+		if methods.Controller != nil {
+			controller = methods.Controller
+		}
+		if methods.Process != nil {
+			process = methods.Process
+		}
+		if methods.Leave != nil {
+			leave = methods.Leave
+		}
+	}
+
+	if controller != nil {
+		nd.controller = append(nd.controller, &DirectiveControllerInfo{name: directive.Name, callback: controller})
+	}
+
+	if process != nil {
+		nd.process = append(nd.process, &DirectiveProcessInfo{
+			name:       directive.Name,
+			callback:   process,
+			terminal:   directive.Terminal,
+			stream:     directive.Stream,
+			transclude: transclude,
+		})
+	}
+
+	if leave != nil {
+		nd.leave = append(nd.leave, &DirectiveLeaveInfo{name: directive.Name, callback: leave})
+	}
+}
+
+// addElementDirective adds the methods of the directive to each rendering of the transcluded element
+// (transclude = "element")
+func (nd *DynamicDirectives) addElementDirective(directive *Directive, methods *DirectiveMethods) {
+	nd.elementDirectives = append(nd.elementDirectives, &directiveRef{directive: directive, methods: methods})
+
+	process, leave := directive.Process, directive.Leave
+	if methods != nil {
+		if methods.Process != nil {
+			process = methods.Process
+		}
+		if methods.Leave != nil {
+			leave = methods.Leave
+		}
+	}
+
+	if process != nil {
+		nd.elementProcess = append(nd.elementProcess, &DirectiveProcessInfo{name: directive.Name, callback: process})
+	}
+
+	if leave != nil {
+		nd.elementLeave = append(nd.elementLeave, &DirectiveLeaveInfo{name: directive.Name, callback: leave})
+	}
+}
+
+// createElementStatic static parts of an element whose attributes and content are dynamic
+func createElementStatic(tag string) *[]string {
+	if HtmlVoidElements[tag] {
+		return &[]string{"<" + tag, "/>"}
+	}
+	return &[]string{"<" + tag, ">", "</" + tag + ">"}
+}
+
+// renderElement renders the element with the given attributes and content
+func (nd *DynamicDirectives) renderElement(attrs *Attributes, content *Rendered) *Rendered {
+	rendered := &Rendered{
+		Static:      nd.elementStatic,
+		Fingerprint: nd.elementFingerprint,
+		Dynamics:    []interface{}{attrs.Render()},
+	}
+	if len(*nd.elementStatic) > 2 {
+		rendered.Dynamics = append(rendered.Dynamics, content)
+	}
+	if content != nil {
+		rendered.Assets = content.Assets
+		content.Assets = nil
+	}
+	return rendered
+}
+
+// writeElement same as renderElement, writing the element to out
+func (nd *DynamicDirectives) writeElement(out *renderWriter, attrs *Attributes, content func()) {
+	static := *nd.elementStatic
+	out.writeStatic(static[0])
+	out.writeDynamic(attrs.Render())
+	out.writeStatic(static[1])
+	if len(static) > 2 {
+		content()
+		out.writeStatic(static[2])
+	}
 }
 
 //Compile    DirectiveCompileFunc
@@ -127,11 +267,50 @@ type DynamicDirectives struct {
 //Leave      DirectiveLeaveFunc
 
 func (nd *DynamicDirectives) Exec(scope *Scope) interface{} {
+	return nd.exec(scope, nil)
+}
+
+// render writes the result of the directives to out, the content transcluded by a Directive.Stream is written as it
+// is rendered
+func (nd *DynamicDirectives) render(out *renderWriter, scope *Scope) {
+	out.writeDynamic(nd.exec(scope, out))
+}
+
+// streamedProcess the process whose transcluded content is written directly to out (see Directive.Stream), nil when
+// not rendering to a writer or when the result of the process is not the output of the element
+func (nd *DynamicDirectives) streamedProcess(out *renderWriter) *DirectiveProcessInfo {
+	if out == nil || nd.hasTemplate || nd.scopeElement {
+		return nil
+	}
+	var last *DirectiveProcessInfo
+	for _, process := range nd.process {
+		if process.transclude {
+			last = process
+		}
+	}
+	if last != nil && last.stream {
+		return last
+	}
+	return nil
+}
+
+// exec when out is informed, the content of the streamed process is written to out and is not part of the result
+func (nd *DynamicDirectives) exec(scope *Scope, out *renderWriter) interface{} {
 	attrs := nd.attrs.Clone()
 
-	//for each Controller
-	//  exec Controller()
-	//end
+	// the scope used by the transcluded content
+	transcludeScope := scope
+	if nd.scope {
+		scope = scope.New(nd.isolateScope)
+		if !nd.isolateScope {
+			transcludeScope = scope
+		}
+	}
+
+	// CONTROLLER
+	for _, controller := range nd.controller {
+		controller.callback(scope)
+	}
 
 	var rendered *Rendered
 
@@ -141,17 +320,32 @@ func (nd *DynamicDirectives) Exec(scope *Scope) interface{} {
 			process.callback(scope, attrs, nil)
 		}
 	} else {
+		streamed := nd.streamedProcess(out)
 		var transcludeFn TranscludeFunc
 		for _, process := range nd.process {
 			if !process.transclude {
 				process.callback(scope, attrs, nil)
+			} else if process == streamed {
+				// the transcluded content is already written, only the content of the process itself is returned
+				rendered = process.callback(scope, attrs, nd.createTranscludeFn(transcludeScope, attrs, out))
 			} else {
 				if transcludeFn == nil {
-					transcludeFn = nd.createTranscludeFn(scope, attrs)
+					transcludeFn = nd.createTranscludeFn(transcludeScope, attrs, nil)
 				}
 				rendered = process.callback(scope, attrs, transcludeFn)
 			}
 		}
+
+		if nd.hasTemplate || nd.scopeElement {
+			if transcludeFn == nil {
+				transcludeFn = nd.createTranscludeFn(transcludeScope, attrs, nil)
+			}
+			if nd.hasTemplate {
+				rendered = nd.renderTemplate(scope, attrs, transcludeFn)
+			} else {
+				rendered = nd.renderElement(attrs, transcludeFn("", nil))
+			}
+		}
 	}
 
 	// RECURSION
@@ -161,7 +355,6 @@ func (nd *DynamicDirectives) Exec(scope *Scope) interface{} {
 
 	// LEAVE
 	for _, directive := range nd.leave {
-		//fnScope := NewRootScope()
 		directive.callback(scope)
 	}
 
@@ -172,12 +365,29 @@ func (nd *DynamicDirectives) Exec(scope *Scope) interface{} {
 	}
 }
 
+// renderTemplate renders the template of the directive, the original content of the element is available through the
+// transclude function of the template scope
+func (nd *DynamicDirectives) renderTemplate(scope *Scope, attrs *Attributes, transcludeFn TranscludeFunc) *Rendered {
+	var content *Rendered
+	if nd.template != nil {
+		templateScope := scope.New(false)
+		templateScope.transclude = transcludeFn
+		content = nd.template.Exec(templateScope)
+	}
+
+	if nd.templateReplace {
+		return content
+	}
+	return nd.renderElement(attrs, content)
+}
+
 // when empty content, removed by directives
 var noopTranscludeFn = func(slot string, preRender func(scope *Scope)) *Rendered {
 	return &Rendered{}
 }
 
-func (nd *DynamicDirectives) createTranscludeFn(scope *Scope, attrs *Attributes) TranscludeFunc {
+// createTranscludeFn when out is informed, the transcluded content is written to out and the function returns nil
+func (nd *DynamicDirectives) createTranscludeFn(scope *Scope, attrs *Attributes, out *renderWriter) TranscludeFunc {
 	slots := nd.transcludeSlots
 	if slots == nil {
 		return noopTranscludeFn
@@ -194,26 +404,35 @@ func (nd *DynamicDirectives) createTranscludeFn(scope *Scope, attrs *Attributes)
 				preRender(transcludeScope)
 			}
 
-			var contentRendered *Rendered
+			// each rendering of the element has its own attributes
+			elementAttrs := attrs.Clone()
+			for _, process := range nd.elementProcess {
+				process.callback(transcludeScope, elementAttrs, nil)
+			}
+
 			contenCompiled, exist := slots["*"]
-			if exist {
+			if out != nil {
+				nd.writeElement(out, elementAttrs, func() {
+					if exist && contenCompiled != nil {
+						contenCompiled.render(out, transcludeScope)
+					}
+				})
+				for _, leave := range nd.elementLeave {
+					leave.callback(transcludeScope)
+				}
+				return nil
+			}
+
+			var contentRendered *Rendered
+			if exist && contenCompiled != nil {
 				contentRendered = contenCompiled.Exec(transcludeScope)
 			}
 
-			return &Rendered{
-				Static: &[]string{
-					"<" + nd.tag,
-					// dynamic attributes
-					">",
-					// dynamic content
-					"</" + nd.tag + ">",
-					// @TODO: Fingerprint, Assets
-				},
-				Dynamics: []interface{}{
-					attrs.Render(),
-					contentRendered,
-				},
+			for _, leave := range nd.elementLeave {
+				leave.callback(transcludeScope)
 			}
+
+			return nd.renderElement(elementAttrs, contentRendered)
 		}
 	}
 
@@ -224,7 +443,7 @@ func (nd *DynamicDirectives) createTranscludeFn(scope *Scope, attrs *Attributes)
 		}
 
 		compiled, exist := slots[slot]
-		if !exist {
+		if !exist || compiled == nil {
 			return nil
 		}
 
@@ -234,6 +453,10 @@ func (nd *DynamicDirectives) createTranscludeFn(scope *Scope, attrs *Attributes)
 			preRender(transcludeScope)
 		}
 
+		if out != nil {
+			compiled.render(out, transcludeScope)
+			return nil
+		}
 		return compiled.Exec(transcludeScope)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/syntax-framework/shtml/cmn"
 	"log"
 	"sort"
+	"sync"
 )
 
 type DirectivesByPriority []*Directive
@@ -27,24 +28,38 @@ type Directives struct {
 	parent *Directives
 	list   []*Directive
 	byName map[string][]*Directive
+	mu     sync.RWMutex
 }
 
 // Contains verifica se essa directiva já está registrada nessa lista
 func (d *Directives) Contains(directive *Directive) bool {
+	d.mu.RLock()
+	contains := d.contains(directive)
+	d.mu.RUnlock()
+	if !contains && d.parent != nil {
+		return d.parent.Contains(directive)
+	}
+	return contains
+}
+
+// contains d.mu must be locked
+func (d *Directives) contains(directive *Directive) bool {
 	for _, o := range d.list {
 		if o == directive {
 			return true
 		}
 	}
-	if d.parent != nil {
-		return d.parent.Contains(directive)
-	}
 	return false
 }
 
 // Add a new directive.
 func (d *Directives) Add(directive *Directive) {
-	if !d.Contains(directive) {
+	if d.parent != nil && d.parent.Contains(directive) {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.contains(directive) {
 		directive.Normalize()
 		d.list = append(d.list, directive)
 		_, exists := d.byName[directive.Name]
@@ -58,6 +73,22 @@ func (d *Directives) Add(directive *Directive) {
 	}
 }
 
+// find gets the directive by name and restriction
+func (d *Directives) find(name string, restrict DirectiveRestrict) *Directive {
+	d.mu.RLock()
+	for _, directive := range d.byName[name] {
+		if directive.Restrict == restrict {
+			d.mu.RUnlock()
+			return directive
+		}
+	}
+	d.mu.RUnlock()
+	if d.parent != nil {
+		return d.parent.find(name, restrict)
+	}
+	return nil
+}
+
 // NewChild cria uma nova lista, que mantém referencia para a lista atual
 func (d *Directives) NewChild() *Directives {
 	return &Directives{parent: d}
@@ -69,7 +100,7 @@ var errorAttrInterpolation = cmn.Err(
 )
 
 // collect Looks for directives on the given node and adds them to the directive collection which is sorted.
-func (d *Directives) collect(node *Node, attrs *Attributes, ignore *Directive) ([]*Directive, error) {
+func (d *Directives) collect(node *Node, attrs *Attributes, ignore *Directive, system *TemplateSystem) ([]*Directive, error) {
 
 	ddMap := map[*Directive]bool{}
 
@@ -77,8 +108,8 @@ func (d *Directives) collect(node *Node, attrs *Attributes, ignore *Directive) (
 	d.collectInto(ddMap, NormalizeName(node.Data), ELEMENT, ignore)
 
 	// iterate over the Map
-	for _, attr := range attrs.Map {
-		err := addAttrInterpolateDirective(ddMap, attr.Value, attr.Name)
+	for _, attr := range attrs.List() {
+		err := addAttrInterpolateDirective(system, ddMap, attr.Value, attr.Name)
 		if err != nil {
 			return nil, errorAttrInterpolation(attr.Name, attr.Value, node.DebugTag(), err.Error())
 		}
@@ -97,20 +128,30 @@ func (d *Directives) collect(node *Node, attrs *Attributes, ignore *Directive) (
 }
 
 func (d *Directives) collectInto(ddMap map[*Directive]bool, name string, location DirectiveRestrict, ignore *Directive) {
-	if directives, exist := d.byName[name]; exist {
-		for _, directive := range directives {
-			if directive.Restrict&location != 0 && directive != ignore {
-				ddMap[directive] = true
-			}
+	d.mu.RLock()
+	for _, directive := range d.byName[name] {
+		if directive.Restrict&location != 0 && directive != ignore {
+			ddMap[directive] = true
 		}
 	}
+	d.mu.RUnlock()
 	if d.parent != nil {
 		d.parent.collectInto(ddMap, name, location, ignore)
 	}
 }
 
-func addAttrInterpolateDirective(directives map[*Directive]bool, value string, name string) error {
-	interpolateFn, err := Interpolate(value)
+// attrInterpolateDirectiveName the directive that interpolates the value of an attribute (<div class="{type}">)
+const attrInterpolateDirectiveName = "AttrInterpolateDirective"
+
+// attrInterpolateDirective used when decoding a Compiled, the interpolation is restored from the config
+var attrInterpolateDirective = &Directive{
+	Name:     attrInterpolateDirectiveName,
+	Priority: 300,
+	Restore:  restoreAttrInterpolate,
+}
+
+func addAttrInterpolateDirective(s *TemplateSystem, directives map[*Directive]bool, value string, name string) error {
+	interpolateFn, err := s.Interpolate(value)
 	if err != nil {
 		return err
 	}
@@ -121,43 +162,67 @@ func addAttrInterpolateDirective(directives map[*Directive]bool, value string, n
 	}
 
 	directive := Directive{
-		Name:     "AttrInterpolateDirective",
+		Name:     attrInterpolateDirectiveName,
 		Priority: 300,
+		Compile: func(node *Node, attrs *Attributes, c *Compiler) (*DirectiveMethods, error) {
+			// checked here, after the directives with higher priority declare their locals (Ex. <li for="item in items">)
+			c.checkInterpolation(interpolateFn, node)
+			interpolateFn.locate(node)
+			return createAttrInterpolate(s, name, value, interpolateFn), nil
+		},
+		Restore: restoreAttrInterpolate,
+	}
+	directives[&directive] = true
+	return nil
+}
+
+// restoreAttrInterpolate see Directive.Restore
+func restoreAttrInterpolate(config map[string]interface{}, s *TemplateSystem) (*DirectiveMethods, error) {
+	name, _ := config["name"].(string)
+	value, _ := config["value"].(string)
+	interpolateFn, err := s.Interpolate(value)
+	if err != nil {
+		return nil, err
+	}
+	return createAttrInterpolate(s, name, value, interpolateFn), nil
+}
+
+func createAttrInterpolate(system *TemplateSystem, name string, value string, interpolateFn *Compiled) *DirectiveMethods {
+	return &DirectiveMethods{
+		Config: map[string]interface{}{"name": name, "value": value},
 		Process: func(s *Scope, attr *Attributes, transclude TranscludeFunc) *Rendered {
 
+			// the compiled interpolation is shared by the concurrent renders, a changed value is interpolated on each render
+			current := interpolateFn
+
 			// If the attribute has changed since last Interpolate()
 			newValue := attr.Get(name)
 			if newValue != value {
 				// we need to interpolate again since the attribute value has been updated
 				// (e.g. by another directive's compile function)
-				// ensure unset/empty values make interpolateFn falsy
+				// ensure unset/empty values make current falsy
+				current = nil
 				if newValue != "" {
-					exp, err := Interpolate(newValue)
+					exp, err := system.Interpolate(newValue)
 					if err != nil {
 						// @TODO: Log.Warning
 						log.Print(err)
-						interpolateFn = nil
 					} else {
-						interpolateFn = exp
+						current = exp
 					}
-				} else {
-					interpolateFn = nil
 				}
-				value = newValue
 			}
 
 			// if attribute was updated so that there is no interpolation going on we don't want to
 			// register any observers
-			if interpolateFn != nil {
+			if current != nil {
 				// initialize attr object so that it's ready in case we need the value for isolate
 				// scope initialization, otherwise the value would not be available from isolate
 				// directive's linking fn during linking phase
-				attr.Set(name, interpolateFn.Exec(s).String())
+				attr.Set(name, current.Exec(s).String())
 			}
 
 			return nil
 		},
 	}
-	directives[&directive] = true
-	return nil
 }
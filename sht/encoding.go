@@ -0,0 +1,719 @@
+package sht
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/syntax-framework/shtml/cmn"
+	"sort"
+)
+
+// encodingVersion version of the format generated by Compiled.Encode, a Compiled encoded by another version must be
+// compiled again
+const encodingVersion = 2
+
+var errorCompiledEncode = cmn.Err(
+	"compiled.encode",
+	"Error while encoding the compiled template.", "Cause: %s",
+)
+
+var errorCompiledEncodeDirective = cmn.Err(
+	"compiled.encode.directive",
+	"The directive can not be encoded, it returns methods from Compile but does not implement Restore.", "Directive: %s",
+)
+
+var errorCompiledDecode = cmn.Err(
+	"compiled.decode",
+	"Error while decoding the compiled template.", "Cause: %s",
+)
+
+var errorCompiledDecodeVersion = cmn.Err(
+	"compiled.decode.version",
+	"The compiled template was encoded by an incompatible version.", "Version: %d", "Expected: %d",
+)
+
+var errorCompiledDecodeDirective = cmn.Err(
+	"compiled.decode.directive",
+	"The directive used by the compiled template is not registered.", "Directive: %s", "Restrict: %d",
+)
+
+// encodedTemplate the root of the format, a Compiled and everything that it references
+type encodedTemplate struct {
+	Version    int                 `json:"version"`
+	Root       int                 `json:"root"`
+	Compiled   []*encodedCompiled  `json:"compiled"`
+	Assets     []*encodedAsset     `json:"assets,omitempty"`
+	Components []*encodedComponent `json:"components,omitempty"`
+}
+
+type encodedCompiled struct {
+	Static      []string          `json:"static"`
+	Dynamics    []*encodedDynamic `json:"dynamics,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Root        bool              `json:"root,omitempty"`
+	Assets      []int             `json:"assets,omitempty"`
+}
+
+// encodedDynamic Kind is one of "interpolate", "interpolate.escaped", "compiled" and "directives"
+type encodedDynamic struct {
+	Kind       string             `json:"kind"`
+	Expression string             `json:"expression,omitempty"`
+	Location   *Location          `json:"location,omitempty"` // of the expression
+	Compiled   *int               `json:"compiled,omitempty"`
+	Directives *encodedDirectives `json:"directives,omitempty"`
+}
+
+type encodedDirectives struct {
+	Tag                string                 `json:"tag,omitempty"`
+	Attrs              []*Attribute           `json:"attrs,omitempty"` // in the order of the source
+	Scope              bool                   `json:"scope,omitempty"`
+	IsolateScope       bool                   `json:"isolateScope,omitempty"`
+	ScopeElement       bool                   `json:"scopeElement,omitempty"`
+	Terminal           bool                   `json:"terminal,omitempty"`
+	Transclude         bool                   `json:"transclude,omitempty"`
+	TranscludeElement  bool                   `json:"transcludeElement,omitempty"`
+	TranscludeSlots    map[string]*int        `json:"transcludeSlots,omitempty"`
+	ElementStatic      *[]string              `json:"elementStatic,omitempty"`
+	ElementFingerprint string                 `json:"elementFingerprint,omitempty"`
+	HasTemplate        bool                   `json:"hasTemplate,omitempty"`
+	Template           *int                   `json:"template,omitempty"`
+	TemplateReplace    bool                   `json:"templateReplace,omitempty"`
+	Directives         []*encodedDirectiveRef `json:"directives,omitempty"`
+	ElementDirectives  []*encodedDirectiveRef `json:"elementDirectives,omitempty"`
+}
+
+// encodedDirectiveRef the directive is looked up by Name and Restrict in the Directives of the TemplateSystem
+type encodedDirectiveRef struct {
+	Name       string                 `json:"name"`
+	Restrict   DirectiveRestrict      `json:"restrict,omitempty"`
+	Transclude bool                   `json:"transclude,omitempty"`
+	Restore    bool                   `json:"restore,omitempty"` // Directive.Restore must be invoked
+	Config     map[string]interface{} `json:"config,omitempty"`
+}
+
+type encodedAsset struct {
+	Content        []byte            `json:"content,omitempty"`
+	Name           string            `json:"name"`
+	Size           int64             `json:"size,omitempty"`
+	Etag           string            `json:"etag,omitempty"`
+	Url            string            `json:"url,omitempty"`
+	Type           cmn.AssetType     `json:"type"`
+	Integrity      string            `json:"integrity,omitempty"`
+	CrossOrigin    string            `json:"crossOrigin,omitempty"`
+	ReferrerPolicy string            `json:"referrerPolicy,omitempty"`
+	Filepath       string            `json:"filepath,omitempty"`
+	Dependencies   []int             `json:"dependencies,omitempty"`
+	Priority       int               `json:"priority,omitempty"`
+	Attributes     map[string]string `json:"attributes,omitempty"`
+}
+
+type encodedComponent struct {
+	Name     string               `json:"name"`
+	File     string               `json:"file,omitempty"`
+	Params   []cmn.ComponentParam `json:"params,omitempty"`
+	Compiled *int                 `json:"compiled,omitempty"`
+	Assets   []int                `json:"assets,omitempty"`
+	Events   map[string]int       `json:"events,omitempty"`
+}
+
+// references to *Compiled and *Component inside DirectiveMethods.Config
+const (
+	encodedConfigCompiled  = "$compiled"
+	encodedConfigComponent = "$component"
+)
+
+// encoder assigns an id to each Compiled, Asset and Component, so the shared references are encoded only once
+type encoder struct {
+	out        *encodedTemplate
+	compiled   map[*Compiled]int
+	assets     map[*cmn.Asset]int
+	components map[*Component]int
+}
+
+// Encode encodes the Compiled in a stable JSON format, allowing templates to be compiled ahead of time and loaded at
+// startup by TemplateSystem.Decode.
+//
+// The directives are referenced by name, so the TemplateSystem that decodes must have the same directives registered.
+// Directives whose Compile returns methods must implement Restore.
+func (c *Compiled) Encode() ([]byte, error) {
+	e := &encoder{
+		out:        &encodedTemplate{Version: encodingVersion},
+		compiled:   map[*Compiled]int{},
+		assets:     map[*cmn.Asset]int{},
+		components: map[*Component]int{},
+	}
+
+	root, err := e.encodeCompiled(c)
+	if err != nil {
+		return nil, err
+	}
+	e.out.Root = root
+
+	data, err := json.Marshal(e.out)
+	if err != nil {
+		return nil, errorCompiledEncode(err.Error())
+	}
+	return data, nil
+}
+
+func (e *encoder) encodeCompiled(compiled *Compiled) (int, error) {
+	if id, exists := e.compiled[compiled]; exists {
+		return id, nil
+	}
+
+	id := len(e.out.Compiled)
+	encoded := &encodedCompiled{
+		Static:      compiled.static,
+		Fingerprint: compiled.fingerprint,
+		Root:        compiled.root,
+	}
+	e.compiled[compiled] = id
+	e.out.Compiled = append(e.out.Compiled, encoded)
+
+	for _, asset := range compiled.Assets {
+		encoded.Assets = append(encoded.Assets, e.encodeAsset(asset))
+	}
+
+	for _, dynamic := range compiled.dynamics {
+		encodedDyn, err := e.encodeDynamic(dynamic)
+		if err != nil {
+			return 0, err
+		}
+		encoded.Dynamics = append(encoded.Dynamics, encodedDyn)
+	}
+
+	return id, nil
+}
+
+// encodeCompiledRef nil when the Compiled is nil (empty content)
+func (e *encoder) encodeCompiledRef(compiled *Compiled) (*int, error) {
+	if compiled == nil {
+		return nil, nil
+	}
+	id, err := e.encodeCompiled(compiled)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (e *encoder) encodeDynamic(dynamic Dynamic) (*encodedDynamic, error) {
+	switch d := dynamic.(type) {
+	case *DynamicInterpolate:
+		return &encodedDynamic{Kind: "interpolate", Expression: d.expression.source, Location: d.expression.location}, nil
+	case *DynamicInterpolateEscaped:
+		return &encodedDynamic{
+			Kind: "interpolate.escaped", Expression: d.expression.source, Location: d.expression.location,
+		}, nil
+	case *DynamicCompiled:
+		id, err := e.encodeCompiledRef(d.Compiled)
+		if err != nil {
+			return nil, err
+		}
+		return &encodedDynamic{Kind: "compiled", Compiled: id}, nil
+	case *DynamicDirectives:
+		directives, err := e.encodeDirectives(d)
+		if err != nil {
+			return nil, err
+		}
+		return &encodedDynamic{Kind: "directives", Directives: directives}, nil
+	}
+	return nil, errorCompiledEncode(fmt.Sprintf("the dynamic %T is not supported", dynamic))
+}
+
+func (e *encoder) encodeDirectives(d *DynamicDirectives) (*encodedDirectives, error) {
+	var err error
+	encoded := &encodedDirectives{
+		Tag:                d.tag,
+		Attrs:              d.attrs.List(),
+		Scope:              d.scope,
+		IsolateScope:       d.isolateScope,
+		ScopeElement:       d.scopeElement,
+		Terminal:           d.terminal,
+		Transclude:         d.transclude,
+		TranscludeElement:  d.transcludeElement,
+		ElementStatic:      d.elementStatic,
+		ElementFingerprint: d.elementFingerprint,
+		HasTemplate:        d.hasTemplate,
+		TemplateReplace:    d.templateReplace,
+	}
+
+	if encoded.Template, err = e.encodeCompiledRef(d.template); err != nil {
+		return nil, err
+	}
+
+	if d.transcludeSlots != nil {
+		encoded.TranscludeSlots = map[string]*int{}
+		for _, slot := range sortedSlotNames(d.transcludeSlots) {
+			if encoded.TranscludeSlots[slot], err = e.encodeCompiledRef(d.transcludeSlots[slot]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if encoded.Directives, err = e.encodeDirectiveRefs(d.directives); err != nil {
+		return nil, err
+	}
+	if encoded.ElementDirectives, err = e.encodeDirectiveRefs(d.elementDirectives); err != nil {
+		return nil, err
+	}
+
+	return encoded, nil
+}
+
+func (e *encoder) encodeDirectiveRefs(refs []*directiveRef) ([]*encodedDirectiveRef, error) {
+	var encoded []*encodedDirectiveRef
+	for _, ref := range refs {
+		encodedRef := &encodedDirectiveRef{
+			Name:       ref.directive.Name,
+			Restrict:   ref.directive.Restrict,
+			Transclude: ref.transclude,
+		}
+
+		methods := ref.methods
+		if methods != nil && (methods.Controller != nil || methods.Process != nil || methods.Leave != nil) {
+			if ref.directive.Restore == nil {
+				return nil, errorCompiledEncodeDirective(ref.directive.Name)
+			}
+			encodedRef.Restore = true
+
+			config, err := e.encodeConfigValue(methods.Config)
+			if err != nil {
+				return nil, err
+			}
+			encodedRef.Config, _ = config.(map[string]interface{})
+		}
+
+		encoded = append(encoded, encodedRef)
+	}
+	return encoded, nil
+}
+
+// encodeConfigValue replaces the references to *Compiled and *Component by their ids
+func (e *encoder) encodeConfigValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64, int:
+		return v, nil
+	case *Compiled:
+		if v == nil {
+			return nil, nil
+		}
+		id, err := e.encodeCompiled(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{encodedConfigCompiled: id}, nil
+	case *Component:
+		id, err := e.encodeComponent(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{encodedConfigComponent: id}, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			encoded, err := e.encodeConfigValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = encoded
+		}
+		return list, nil
+	case map[string]interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		object := map[string]interface{}{}
+		for _, key := range keys {
+			encoded, err := e.encodeConfigValue(v[key])
+			if err != nil {
+				return nil, err
+			}
+			object[key] = encoded
+		}
+		return object, nil
+	}
+	return nil, errorCompiledEncode(fmt.Sprintf("the config value %T is not supported", value))
+}
+
+func (e *encoder) encodeComponent(component *Component) (int, error) {
+	if id, exists := e.components[component]; exists {
+		return id, nil
+	}
+
+	id := len(e.out.Components)
+	encoded := &encodedComponent{
+		Name:   component.Name,
+		File:   component.File,
+		Params: component.Params,
+		Events: component.Events,
+	}
+	e.components[component] = id
+	e.out.Components = append(e.out.Components, encoded)
+
+	for _, asset := range component.Assets {
+		encoded.Assets = append(encoded.Assets, e.encodeAsset(asset))
+	}
+
+	var err error
+	if encoded.Compiled, err = e.encodeCompiledRef(component.Compiled); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (e *encoder) encodeAsset(asset *cmn.Asset) int {
+	if id, exists := e.assets[asset]; exists {
+		return id
+	}
+
+	id := len(e.out.Assets)
+	encoded := &encodedAsset{
+		Content:        asset.Content,
+		Name:           asset.Name,
+		Size:           asset.Size,
+		Etag:           asset.Etag,
+		Url:            asset.Url,
+		Type:           asset.Type,
+		Integrity:      asset.Integrity,
+		CrossOrigin:    asset.CrossOrigin,
+		ReferrerPolicy: asset.ReferrerPolicy,
+		Filepath:       asset.Filepath,
+		Priority:       asset.Priority,
+		Attributes:     asset.Attributes,
+	}
+	e.assets[asset] = id
+	e.out.Assets = append(e.out.Assets, encoded)
+
+	for _, dependency := range asset.Dependencies {
+		encoded.Dependencies = append(encoded.Dependencies, e.encodeAsset(dependency))
+	}
+	return id
+}
+
+func sortedSlotNames(slots map[string]*Compiled) []string {
+	names := make([]string, 0, len(slots))
+	for name := range slots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decoder the Compiled, Asset and Component are created before being filled, so they can be referenced in any order
+type decoder struct {
+	system     *TemplateSystem
+	in         *encodedTemplate
+	compiled   []*Compiled
+	assets     []*cmn.Asset
+	components []*Component
+}
+
+// Decode decodes a Compiled encoded by Compiled.Encode. The directives are looked up by name in the Directives of the
+// system, the components and assets used by the template are registered in the system.
+func (s *TemplateSystem) Decode(data []byte) (*Compiled, error) {
+	in := &encodedTemplate{}
+	if err := json.Unmarshal(data, in); err != nil {
+		return nil, errorCompiledDecode(err.Error())
+	}
+
+	if in.Version != encodingVersion {
+		return nil, errorCompiledDecodeVersion(in.Version, encodingVersion)
+	}
+
+	d := &decoder{system: s, in: in}
+
+	for range in.Compiled {
+		d.compiled = append(d.compiled, &Compiled{})
+	}
+
+	if err := d.decodeAssets(); err != nil {
+		return nil, err
+	}
+
+	if err := d.decodeComponents(); err != nil {
+		return nil, err
+	}
+
+	for i, encoded := range in.Compiled {
+		if err := d.decodeCompiled(d.compiled[i], encoded); err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := d.compiledRef(&in.Root)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, errorCompiledDecode("the root template was not found")
+	}
+	return root, nil
+}
+
+// decodeAssets assets with the same name of an asset registered in the system are reused
+func (d *decoder) decodeAssets() error {
+	d.system.mu.Lock()
+	defer d.system.mu.Unlock()
+
+	registered := map[string]*cmn.Asset{}
+	for asset := range d.system.Assets {
+		registered[asset.Name] = asset
+	}
+
+	for _, encoded := range d.in.Assets {
+		asset := registered[encoded.Name]
+		if asset == nil {
+			asset = &cmn.Asset{
+				Content:        encoded.Content,
+				Name:           encoded.Name,
+				Size:           encoded.Size,
+				Etag:           encoded.Etag,
+				Url:            encoded.Url,
+				Type:           encoded.Type,
+				Integrity:      encoded.Integrity,
+				CrossOrigin:    encoded.CrossOrigin,
+				ReferrerPolicy: encoded.ReferrerPolicy,
+				Filepath:       encoded.Filepath,
+				Priority:       encoded.Priority,
+				Attributes:     encoded.Attributes,
+			}
+			// the name was already resolved when compiling, there is no asset with the same name
+			d.system.registerAsset(asset)
+			registered[asset.Name] = asset
+		}
+		d.assets = append(d.assets, asset)
+	}
+
+	for i, encoded := range d.in.Assets {
+		if len(d.assets[i].Dependencies) > 0 {
+			continue // registered before the decoding
+		}
+		for _, dependency := range encoded.Dependencies {
+			asset, err := d.assetRef(dependency)
+			if err != nil {
+				return err
+			}
+			d.assets[i].Dependencies = append(d.assets[i].Dependencies, asset)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeComponents() error {
+	for _, encoded := range d.in.Components {
+		component := &Component{
+			Name:   encoded.Name,
+			File:   encoded.File,
+			Params: encoded.Params,
+			Events: encoded.Events,
+		}
+		var err error
+		if component.Compiled, err = d.compiledRef(encoded.Compiled); err != nil {
+			return err
+		}
+		for _, id := range encoded.Assets {
+			asset, err := d.assetRef(id)
+			if err != nil {
+				return err
+			}
+			component.Assets = append(component.Assets, asset)
+		}
+		if err = d.system.RegisterComponent(component); err != nil {
+			return err
+		}
+		d.components = append(d.components, component)
+	}
+	return nil
+}
+
+func (d *decoder) decodeCompiled(compiled *Compiled, encoded *encodedCompiled) error {
+	compiled.static = encoded.Static
+	compiled.fingerprint = encoded.Fingerprint
+	compiled.root = encoded.Root
+
+	for _, id := range encoded.Assets {
+		asset, err := d.assetRef(id)
+		if err != nil {
+			return err
+		}
+		compiled.Assets = append(compiled.Assets, asset)
+	}
+
+	for _, encodedDyn := range encoded.Dynamics {
+		dynamic, err := d.decodeDynamic(encodedDyn)
+		if err != nil {
+			return err
+		}
+		compiled.dynamics = append(compiled.dynamics, dynamic)
+	}
+	return nil
+}
+
+func (d *decoder) decodeDynamic(encoded *encodedDynamic) (Dynamic, error) {
+	switch encoded.Kind {
+	case "interpolate", "interpolate.escaped":
+		expression, err := d.system.ParseExpression(encoded.Expression)
+		if err != nil {
+			return nil, errorCompiledDecode(err.Error())
+		}
+		if encoded.Location != nil {
+			located := *expression
+			located.location = encoded.Location
+			expression = &located
+		}
+		if encoded.Kind == "interpolate" {
+			return &DynamicInterpolate{expression: expression}, nil
+		}
+		return &DynamicInterpolateEscaped{expression: expression}, nil
+	case "compiled":
+		compiled, err := d.compiledRef(encoded.Compiled)
+		if err != nil {
+			return nil, err
+		}
+		return &DynamicCompiled{Compiled: compiled}, nil
+	case "directives":
+		if encoded.Directives != nil {
+			return d.decodeDirectives(encoded.Directives)
+		}
+	}
+	return nil, errorCompiledDecode(fmt.Sprintf("the dynamic %q is not supported", encoded.Kind))
+}
+
+func (d *decoder) decodeDirectives(encoded *encodedDirectives) (*DynamicDirectives, error) {
+	var err error
+	attrs := NewAttributes()
+	for _, attr := range encoded.Attrs {
+		attrs.Add(attr)
+	}
+	dynamic := &DynamicDirectives{
+		tag:                encoded.Tag,
+		attrs:              attrs,
+		scope:              encoded.Scope,
+		isolateScope:       encoded.IsolateScope,
+		scopeElement:       encoded.ScopeElement,
+		terminal:           encoded.Terminal,
+		transclude:         encoded.Transclude,
+		transcludeElement:  encoded.TranscludeElement,
+		elementStatic:      encoded.ElementStatic,
+		elementFingerprint: encoded.ElementFingerprint,
+		hasTemplate:        encoded.HasTemplate,
+		templateReplace:    encoded.TemplateReplace,
+	}
+
+	if dynamic.template, err = d.compiledRef(encoded.Template); err != nil {
+		return nil, err
+	}
+
+	if encoded.TranscludeSlots != nil {
+		dynamic.transcludeSlots = map[string]*Compiled{}
+		for slot, id := range encoded.TranscludeSlots {
+			if dynamic.transcludeSlots[slot], err = d.compiledRef(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, ref := range encoded.Directives {
+		directive, methods, err := d.decodeDirectiveRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		dynamic.addDirective(directive, methods, ref.Transclude)
+	}
+
+	for _, ref := range encoded.ElementDirectives {
+		directive, methods, err := d.decodeDirectiveRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		dynamic.addElementDirective(directive, methods)
+	}
+
+	return dynamic, nil
+}
+
+func (d *decoder) decodeDirectiveRef(ref *encodedDirectiveRef) (*Directive, *DirectiveMethods, error) {
+	var directive *Directive
+	if ref.Name == attrInterpolateDirectiveName {
+		directive = attrInterpolateDirective
+	} else if d.system.Directives != nil {
+		directive = d.system.Directives.find(ref.Name, ref.Restrict)
+	}
+	if directive == nil {
+		return nil, nil, errorCompiledDecodeDirective(ref.Name, ref.Restrict)
+	}
+
+	if !ref.Restore {
+		return directive, nil, nil
+	}
+	if directive.Restore == nil {
+		return nil, nil, errorCompiledEncodeDirective(directive.Name)
+	}
+
+	config, err := d.decodeConfigValue(ref.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	configMap, _ := config.(map[string]interface{})
+
+	methods, err := directive.Restore(configMap, d.system)
+	if err != nil {
+		return nil, nil, err
+	}
+	return directive, methods, nil
+}
+
+// decodeConfigValue replaces the ids by the *Compiled and *Component referenced
+func (d *decoder) decodeConfigValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			decoded, err := d.decodeConfigValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = decoded
+		}
+	case map[string]interface{}:
+		if id, isCompiled := v[encodedConfigCompiled].(float64); isCompiled && len(v) == 1 {
+			ref := int(id)
+			return d.compiledRef(&ref)
+		}
+		if id, isComponent := v[encodedConfigComponent].(float64); isComponent && len(v) == 1 {
+			if int(id) < 0 || int(id) >= len(d.components) {
+				return nil, errorCompiledDecode(fmt.Sprintf("invalid component reference %v", id))
+			}
+			return d.components[int(id)], nil
+		}
+		for key, item := range v {
+			decoded, err := d.decodeConfigValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = decoded
+		}
+	}
+	return value, nil
+}
+
+func (d *decoder) compiledRef(id *int) (*Compiled, error) {
+	if id == nil {
+		return nil, nil
+	}
+	if *id < 0 || *id >= len(d.compiled) {
+		return nil, errorCompiledDecode(fmt.Sprintf("invalid compiled reference %d", *id))
+	}
+	return d.compiled[*id], nil
+}
+
+func (d *decoder) assetRef(id int) (*cmn.Asset, error) {
+	if id < 0 || id >= len(d.assets) {
+		return nil, errorCompiledDecode(fmt.Sprintf("invalid asset reference %d", id))
+	}
+	return d.assets[id], nil
+}
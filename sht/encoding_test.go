@@ -0,0 +1,133 @@
+package sht
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testEncodeDecode encodes the compiled, decodes it on a new system and checks if the rendering does not change
+func testEncodeDecode(t *testing.T, compiled *Compiled, directives *Directives, values map[string]interface{}, expected string) {
+	data, err := compiled.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := &TemplateSystem{Directives: directives.NewChild()}
+	decoded, err := ts.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	TestRender(t, decoded, values, expected)
+
+	// stable format
+	again, err := decoded.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("compiled.Encode() | unstable encoding\n   first: %s\n  second: %s", data, again)
+	}
+}
+
+func Test_encode_interpolation(t *testing.T) {
+	template := `<div class="out {valueOne ? 'class-true' : 'class-false'}">!{value} {value}</div>`
+	expected := `<div class="out class-true"><b> &lt;b&gt;</div>`
+	values := map[string]interface{}{"valueOne": true, "value": "<b>"}
+
+	compiled, _ := TestCompile(t, template, nil, &Directives{})
+	TestRender(t, compiled, values, expected)
+	testEncodeDecode(t, compiled, &Directives{}, values, expected)
+}
+
+func Test_encode_directives(t *testing.T) {
+	template := `
+    <div>
+      <div test="{label}" class="xpto"><span>{label}</span></div>
+    </div>`
+
+	expected := `
+    <div>
+      <div class="xpto" data-label="value"><span>value</span></div><div class="xpto" data-label="value"><span>value</span></div>
+    </div>`
+
+	values := map[string]interface{}{"label": "value"}
+
+	createMethods := func(times int) *DirectiveMethods {
+		return &DirectiveMethods{
+			Config: map[string]interface{}{"times": float64(times)},
+			Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+				attrs.Set("data-label", attrs.Get("test"))
+				attrs.Remove(attrs.GetAttribute("test"))
+				rendered := &Rendered{}
+				for i := 0; i < times; i++ {
+					rendered.Dynamics = append(rendered.Dynamics, transclude("", nil))
+				}
+				return rendered
+			},
+		}
+	}
+
+	directives := &Directives{}
+	directives.Add(&Directive{
+		Name:       "test",
+		Restrict:   ATTRIBUTE,
+		Priority:   200,
+		Terminal:   true,
+		Transclude: "element",
+		Compile: func(node *Node, attrs *Attributes, t *Compiler) (*DirectiveMethods, error) {
+			return createMethods(2), nil
+		},
+		Restore: func(config map[string]interface{}, s *TemplateSystem) (*DirectiveMethods, error) {
+			return createMethods(int(config["times"].(float64))), nil
+		},
+	})
+
+	compiled, _ := TestCompile(t, template, nil, directives)
+	TestRender(t, compiled, values, expected)
+	testEncodeDecode(t, compiled, directives, values, expected)
+}
+
+func Test_encode_errors(t *testing.T) {
+	directives := &Directives{}
+	directives.Add(&Directive{
+		Name:     "test",
+		Restrict: ATTRIBUTE,
+		Compile: func(node *Node, attrs *Attributes, t *Compiler) (*DirectiveMethods, error) {
+			return &DirectiveMethods{Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+				return nil
+			}}, nil
+		},
+	})
+
+	compiled, _ := TestCompile(t, `<div test></div>`, nil, directives)
+	if _, err := compiled.Encode(); err == nil || !strings.HasPrefix(err.Error(), "[compiled.encode.directive]") {
+		t.Errorf("compiled.Encode() | invalid error\n expected: [compiled.encode.directive] .......\n   actual: %v", err)
+	}
+
+	ts := &TemplateSystem{Directives: &Directives{}}
+	if _, err := ts.Decode([]byte(`{"version":0,"root":0,"compiled":[]}`)); err == nil || !strings.HasPrefix(err.Error(), "[compiled.decode.version]") {
+		t.Errorf("ts.Decode(data) | invalid error\n expected: [compiled.decode.version] .......\n   actual: %v", err)
+	}
+
+	compiled, _ = TestCompile(t, `<div class="{value}"><span test="{value}"></span></div>`, nil, &Directives{})
+	data, err := compiled.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ts.Decode(data); err != nil {
+		t.Errorf("ts.Decode(data) | unexpected error: %v", err)
+	}
+
+	directives = &Directives{}
+	directives.Add(&Directive{Name: "test", Restrict: ATTRIBUTE, Process: func(scope *Scope, attrs *Attributes, transclude TranscludeFunc) *Rendered {
+		return nil
+	}})
+	compiled, _ = TestCompile(t, `<div test></div>`, nil, directives)
+	if data, err = compiled.Encode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ts.Decode(data); err == nil || !strings.HasPrefix(err.Error(), "[compiled.decode.directive]") {
+		t.Errorf("ts.Decode(data) | invalid error\n expected: [compiled.decode.directive] .......\n   actual: %v", err)
+	}
+}
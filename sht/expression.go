@@ -5,24 +5,169 @@ import (
 	"fmt"
 	"github.com/antonmedv/expr"
 	"github.com/antonmedv/expr/vm"
+	"github.com/syntax-framework/shtml/cmn"
 	"io"
-	"log"
+	"reflect"
+	"regexp"
 	"strings"
 )
 
+var errorExpressionFilterUnknown = cmn.Err(
+	"expression.filter.unknown",
+	"The filter is not registered.", "Filter: %s", "Expression: %s",
+)
+
+var errorExpressionFilterSyntax = cmn.Err(
+	"expression.filter.syntax",
+	"Invalid filter, expected name or name(args).", "Filter: %s", "Expression: %s",
+)
+
+var errorExpressionFunc = cmn.Err(
+	"expression.func",
+	"The value of the function is not a function.", "Name: %s", "Type: %T",
+)
+
+var errorRenderUndefined = cmn.Err(
+	"render.undefined",
+	"The variables are not defined in the scope.",
+	"Variables: %s", "Expression: %s", "File: %s", "Line: %d", "Column: %d",
+)
+
+var errorRenderExpression = cmn.Err(
+	"render.expression",
+	"Error while executing the expression.", "Expression: %s", "File: %s", "Line: %d", "Column: %d", "Cause: %s",
+)
+
 type Expression struct {
-	program *vm.Program
+	program  *vm.Program
+	source   string              // the expression, allows to encode the Compiled
+	filters  []*expressionFilter // { name | upper | truncate(20) }
+	env      expressionEnv       // the functions and constants of the system when the expression was compiled
+	location *Location           // where the expression was declared in the template, nil when unknown
+}
+
+// Location a position in a template file
+type Location struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// RenderError an expression that failed while rendering with a strict scope (see Scope.SetStrict)
+type RenderError struct {
+	Expression string
+	Location   *Location // nil when unknown
+	Undefined  []string  // the variables not found in the scope
+	Cause      error     // the error of the execution, nil when Undefined
+}
+
+func (e *RenderError) Error() string {
+	location := e.Location
+	if location == nil {
+		location = &Location{}
+	}
+	if e.Cause == nil {
+		return errorRenderUndefined(
+			strings.Join(e.Undefined, ", "), e.Expression, location.File, location.Line, location.Column,
+		).Error()
+	}
+	return errorRenderExpression(e.Expression, location.File, location.Line, location.Column, e.Cause.Error()).Error()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Cause
+}
+
+// At a copy of the expression that knows where it was declared, used in the RenderError
+func (e *Expression) At(node *Node) *Expression {
+	if e == nil || node == nil {
+		return e
+	}
+	located := *e
+	located.location = &Location{File: node.File, Line: node.Line, Column: node.Column}
+	return &located
+}
+
+// expressionEnv the functions and constants available to the expressions of a system (see TemplateSystem.Funcs)
+type expressionEnv map[string]interface{}
+
+// expressionScope the environment of the execution, the functions and constants have priority over the scope
+type expressionScope struct {
+	env       expressionEnv
+	scope     *Scope
+	undefined []string // when strict, the variables not found in the scope
+}
+
+func (e *expressionScope) Fetch(key interface{}) interface{} {
+	if keyStr, ok := key.(string); ok {
+		if value, exists := e.env[keyStr]; exists {
+			return value
+		}
+		if e.scope.Strict() {
+			if value, exists := e.scope.Get(keyStr); exists {
+				return value
+			}
+			e.undefined = append(e.undefined, keyStr)
+			return ""
+		}
+	}
+	return e.scope.Fetch(key)
+}
+
+// expressionFilter a filter applied to the result of the expression, resolved at compile time
+type expressionFilter struct {
+	name   string
+	filter FilterFunc
+	args   []*Expression
 }
 
 func (e *Expression) Exec(scope *Scope) interface{} {
-	output, err := expr.Run(e.program, scope)
+	output, err := e.run(scope)
 	if err != nil {
-		log.Print(err)
+		scope.ReportError(err)
 		return nil
 	}
 	return output
 }
 
+// run executes the expression and its filters, the errors are *RenderError
+func (e *Expression) run(scope *Scope) (interface{}, error) {
+	var env interface{} = scope
+	var envScope *expressionScope
+	if e.env != nil || scope.Strict() {
+		envScope = &expressionScope{env: e.env, scope: scope}
+		env = envScope
+	}
+	output, err := expr.Run(e.program, env)
+	if err != nil {
+		return nil, &RenderError{Expression: e.source, Location: e.location, Cause: err}
+	}
+	if envScope != nil && len(envScope.undefined) > 0 && !e.defaulted() {
+		return nil, &RenderError{Expression: e.source, Location: e.location, Undefined: envScope.undefined}
+	}
+	for _, f := range e.filters {
+		args := make([]interface{}, len(f.args))
+		for i, arg := range f.args {
+			args[i] = arg.Exec(scope)
+		}
+		if output, err = f.filter(output, args...); err != nil {
+			return nil, &RenderError{Expression: e.source, Location: e.location, Cause: err}
+		}
+	}
+	return output, nil
+}
+
+// defaulted the undefined variables are empty and replaced by the default filter ({ name | default('Anonymous') }), so
+// they are not reported when strict
+func (e *Expression) defaulted() bool {
+	for _, f := range e.filters {
+		if f.name == "default" {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Expression) EvalBool(scope *Scope) bool {
 	if e == nil {
 		return false
@@ -45,24 +190,191 @@ func (e *Expression) EvalString(scope *Scope) string {
 	return fmt.Sprintf("%v", result)
 }
 
-var _cachedExpressions = map[string]*Expression{}
-
-// ParseExpression process a single expression
+// ParseExpression process a single expression, only the DefaultFilters are available and the expression can't call
+// functions (see TemplateSystem.ParseExpression)
 func ParseExpression(exp string) (*Expression, error) {
+	return parseExpression(nil, exp)
+}
+
+// ParseExpression process a single expression. The filters, functions and constants are resolved in the system (see
+// TemplateSystem.Filter and TemplateSystem.Funcs), the expressions are cached by system.
+func (s *TemplateSystem) ParseExpression(exp string) (*Expression, error) {
 	exp = strings.TrimSpace(exp)
-	expression, exists := _cachedExpressions[exp]
+	s.mu.RLock()
+	expression, exists := s.expressions[exp]
+	s.mu.RUnlock()
 	if exists {
 		return expression, nil
 	}
-	program, err := expr.Compile(exp)
+	expression, err := parseExpression(s, exp)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.expressions == nil {
+		s.expressions = map[string]*Expression{}
+	}
+	s.expressions[exp] = expression
+	return expression, nil
+}
+
+// Funcs adds the functions that can be called by the expressions of the templates ({ formatPrice(product.price) }).
+// The calls are checked at compile time, the expressions already compiled are not affected.
+func (s *TemplateSystem) Funcs(funcs map[string]interface{}) error {
+	for name, fn := range funcs {
+		if reflect.ValueOf(fn).Kind() != reflect.Func {
+			return errorExpressionFunc(name, fn)
+		}
+	}
+	s.addEnv(funcs)
+	return nil
+}
+
+// Consts adds the constants that can be used by the expressions of the templates ({ price * TAX }). The constants have
+// priority over the values of the scope with the same name, the expressions already compiled are not affected.
+func (s *TemplateSystem) Consts(consts map[string]interface{}) {
+	s.addEnv(consts)
+}
+
+// addEnv creates a new env, the expressions already compiled keep the previous env
+func (s *TemplateSystem) addEnv(values map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	env := expressionEnv{}
+	for name, value := range s.env {
+		env[name] = value
+	}
+	for name, value := range values {
+		env[name] = value
+	}
+	s.env = env
+	s.expressions = nil
+}
+
+// environment the current env, which is never modified (see addEnv)
+func (s *TemplateSystem) environment() expressionEnv {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.env
+}
+
+var filterRegex = regexp.MustCompile(`(?s)^([a-zA-Z_][a-zA-Z0-9_]*)\s*(?:\((.*)\))?$`)
+
+// parseExpression { value | filter | filter(arg1, arg2) }
+func parseExpression(s *TemplateSystem, exp string) (*Expression, error) {
+	exp = strings.TrimSpace(exp)
+	parts := splitExpression(exp, '|')
+	if len(parts) == 1 {
+		return compileExpression(s, exp)
+	}
+
+	value, err := compileExpression(s, parts[0])
 	if err != nil {
 		return nil, err
 	}
-	expression = &Expression{program: program}
-	_cachedExpressions[exp] = expression
+
+	expression := &Expression{program: value.program, source: exp, env: value.env}
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		match := filterRegex.FindStringSubmatch(part)
+		if match == nil {
+			return nil, errorExpressionFilterSyntax(part, exp)
+		}
+
+		filter := s.Filter(match[1])
+		if filter == nil {
+			return nil, errorExpressionFilterUnknown(match[1], exp)
+		}
+
+		f := &expressionFilter{name: match[1], filter: filter}
+		if strings.TrimSpace(match[2]) != "" {
+			for _, arg := range splitExpression(match[2], ',') {
+				argExpression, err := compileExpression(s, arg)
+				if err != nil {
+					return nil, err
+				}
+				f.args = append(f.args, argExpression)
+			}
+		}
+		expression.filters = append(expression.filters, f)
+	}
 	return expression, nil
 }
 
+// splitExpression splits the expression by the separator, ignoring the separators inside strings, parentheses,
+// brackets and braces. The "||" operator is not a separator.
+func splitExpression(exp string, separator rune) []string {
+	var parts []string
+	runes := []rune(exp)
+	depth := 0
+	start := 0
+	var quote rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if quote != 0 {
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '\'', '"', '`':
+			quote = r
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case separator:
+			if depth != 0 {
+				continue
+			}
+			if separator == '|' && ((i+1 < len(runes) && runes[i+1] == '|') || (i > 0 && runes[i-1] == '|')) {
+				continue
+			}
+			parts = append(parts, string(runes[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// compileExpression compiles an expression without filters. When the system has functions or constants, they are
+// informed to the compiler, which checks the calls. The variables of the scope are not known at compile time.
+func compileExpression(s *TemplateSystem, exp string) (*Expression, error) {
+	exp = strings.TrimSpace(exp)
+	var options []expr.Option
+	env := s.environment()
+	if env != nil {
+		options = append(options, expr.Env(env), expr.AllowUndefinedVariables())
+	}
+	program, err := expr.Compile(exp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{program: program, source: exp, env: env}, nil
+}
+
+// locate sets the location of the expressions of the interpolation, see RenderError
+func (c *Compiled) locate(node *Node) {
+	if c == nil {
+		return
+	}
+	for _, dynamic := range c.dynamics {
+		switch d := dynamic.(type) {
+		case *DynamicInterpolate:
+			d.expression = d.expression.At(node)
+		case *DynamicInterpolateEscaped:
+			d.expression = d.expression.At(node)
+		}
+	}
+}
+
 // DynamicInterpolate parte dinamica de execução de uma expressão
 type DynamicInterpolate struct {
 	expression *Expression
@@ -94,7 +406,20 @@ func (d *DynamicInterpolateEscaped) Exec(scope *Scope) interface{} {
 //
 // exp = Interpolate('Hello {name}!');
 // exp.Exec({name:'Syntax'}).String() == "Hello Syntax!"
+//
+// # The result of the expression can be transformed by filters, only the DefaultFilters are available
+//
+// exp = Interpolate('Hello { name | upper }!');
 func Interpolate(text string) (*Compiled, error) {
+	return interpolate(nil, text)
+}
+
+// Interpolate see Interpolate, the filters are resolved in the system (see TemplateSystem.Filter)
+func (s *TemplateSystem) Interpolate(text string) (*Compiled, error) {
+	return interpolate(s, text)
+}
+
+func interpolate(s *TemplateSystem, text string) (*Compiled, error) {
 
 	if !strings.ContainsRune(text, '{') {
 		return nil, nil
@@ -165,7 +490,7 @@ func Interpolate(text string) (*Compiled, error) {
 						inExpression = false
 
 						value := content.String()
-						program, programErr := ParseExpression(value)
+						program, programErr := parseExpression(s, value)
 						if programErr != nil {
 							return nil, programErr
 						}
@@ -0,0 +1,155 @@
+package sht
+
+import (
+	"fmt"
+	"github.com/syntax-framework/shtml/cmn"
+	"strings"
+	"testing"
+)
+
+func Test_Funcs_Consts(t *testing.T) {
+	newSystem := func() *TemplateSystem {
+		ts := &TemplateSystem{Directives: (&Directives{}).NewChild()}
+		if err := ts.Funcs(map[string]interface{}{
+			"price": func(value float64) string { return fmt.Sprintf("$%.2f", value) },
+			"greet": func(name string) string { return "Hello " + name },
+		}); err != nil {
+			t.Fatal(err)
+		}
+		ts.Consts(map[string]interface{}{"TAX": 0.5, "name": "const"})
+		return ts
+	}
+
+	ts := newSystem()
+
+	compiled, err := NewCompiler(ts).Compile(`<span title="{greet(user)}">{ price(value * TAX) } {name}</span>`, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{"user": "John", "value": 5, "name": "scope"}
+	expected := `<span title="Hello John">$2.50 const</span>`
+	TestRender(t, compiled, values, expected)
+
+	data, err := compiled.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := newSystem().Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	TestRender(t, decoded, values, expected)
+
+	// the calls are checked at compile time
+	for _, template := range []string{`<span>{ greet() }</span>`, `<span>{ greet(TAX) }</span>`} {
+		if _, err = NewCompiler(ts).Compile(template, "template.html"); err == nil {
+			t.Errorf("compiler.Compile(%s) | expect to receive error", template)
+		}
+	}
+
+	if err = ts.Funcs(map[string]interface{}{"invalid": 1}); err == nil || !strings.HasPrefix(err.Error(), "[expression.func]") {
+		t.Errorf("ts.Funcs() | expect to receive expression.func error\n   actual: %v", err)
+	}
+}
+
+func Test_Funcs_System_Cache(t *testing.T) {
+	tsA := &TemplateSystem{Directives: (&Directives{}).NewChild()}
+	tsB := &TemplateSystem{Directives: (&Directives{}).NewChild()}
+	_ = tsA.Funcs(map[string]interface{}{"label": func() string { return "A" }})
+	_ = tsB.Funcs(map[string]interface{}{"label": func() string { return "B" }})
+
+	for ts, expected := range map[*TemplateSystem]string{tsA: "<b>A</b>", tsB: "<b>B</b>"} {
+		compiled, err := NewCompiler(ts).Compile(`<b>{label()}</b>`, "template.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		TestRender(t, compiled, nil, expected)
+	}
+
+	// new functions don't affect the templates already compiled
+	compiled, _ := NewCompiler(tsA).Compile(`<b>{label()}</b>`, "template.html")
+	_ = tsA.Funcs(map[string]interface{}{"label": func() string { return "C" }})
+	TestRender(t, compiled, nil, "<b>A</b>")
+}
+
+func Test_Strict(t *testing.T) {
+	ts := &TemplateSystem{Directives: (&Directives{}).NewChild(), Strict: true}
+	template := "<div>\n  <b>{name}</b>\n  <i title=\"{missing}\">{items[5]}</i>\n  {name | truncate('x')}\n</div>"
+
+	compiled, err := NewCompiler(ts).Compile(template, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := ts.NewScope()
+	scope.Set("name", "John")
+	scope.Set("items", []int{1})
+
+	rendered, err := compiled.Execute(scope)
+	if actual, expected := rendered.String(), "<div>\n  <b>John</b>\n  <i title></i>\n  \n</div>"; actual != expected {
+		t.Errorf("compiled.Execute(scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+
+	errs, isList := err.(cmn.ErrorList)
+	if !isList || len(errs) != 3 {
+		t.Fatalf("compiled.Execute(scope) | expect to receive 3 errors\n   actual: %v", err)
+	}
+
+	expected := []struct {
+		code       string
+		expression string
+		line       int
+	}{
+		{"[render.undefined]", "missing", 3},
+		{"[render.expression]", "items[5]", 3},
+		{"[render.expression]", "name | truncate('x')", 3},
+	}
+	for i, e := range errs {
+		renderErr, isRenderErr := e.(*RenderError)
+		if !isRenderErr || !strings.HasPrefix(e.Error(), expected[i].code) || renderErr.Expression != expected[i].expression {
+			t.Errorf("compiled.Execute(scope) | invalid error\n   actual: %v\n expected: %s %s", e, expected[i].code, expected[i].expression)
+			continue
+		}
+		if renderErr.Location == nil || renderErr.Location.File != "template.html" || renderErr.Location.Line != expected[i].line {
+			t.Errorf("compiled.Execute(scope) | invalid location\n   actual: %+v\n expected: line %d", renderErr.Location, expected[i].line)
+		}
+	}
+
+	// the returned errors are removed from the scope
+	if len(scope.Errors()) != 0 {
+		t.Errorf("compiled.Execute(scope) | expect to remove the errors from the scope\n   actual: %v", scope.Errors())
+	}
+
+	// Render also returns the errors
+	if err = compiled.Render(&strings.Builder{}, scope); err == nil || len(err.(cmn.ErrorList)) != 3 {
+		t.Errorf("compiled.Render(w, scope) | expect to receive 3 errors\n   actual: %v", err)
+	}
+	if len(scope.Errors()) != 0 {
+		t.Errorf("compiled.Render(w, scope) | expect to remove the errors from the scope\n   actual: %v", scope.Errors())
+	}
+
+	// not strict, only logged
+	scope.SetStrict(false)
+	if _, err = compiled.Execute(scope); err != nil {
+		t.Errorf("compiled.Execute(scope) | expect no error when not strict\n   actual: %v", err)
+	}
+}
+
+func Test_Strict_Default(t *testing.T) {
+	ts := &TemplateSystem{Directives: (&Directives{}).NewChild(), Strict: true}
+	compiled, err := NewCompiler(ts).Compile(`<b>{ nickname | default('Anonymous') }</b><i>{ missing | upper }</i>`, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := compiled.Execute(ts.NewScope())
+	if actual, expected := rendered.String(), "<b>Anonymous</b><i></i>"; actual != expected {
+		t.Errorf("compiled.Execute(scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
+
+	// only the expression without the default filter is reported
+	errs, isList := err.(cmn.ErrorList)
+	if !isList || len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "[render.undefined]") {
+		t.Errorf("compiled.Execute(scope) | expect to receive 1 undefined error\n   actual: %v", err)
+	}
+}
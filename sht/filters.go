@@ -0,0 +1,227 @@
+package sht
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FilterFunc transforms the value of an interpolation ({ name | upper | truncate(20) }). The arguments are the results
+// of the expressions informed in the template (truncate(20) -> args = [20])
+type FilterFunc func(value interface{}, args ...interface{}) (interface{}, error)
+
+// DefaultFilters the filters available in all template systems
+var DefaultFilters = map[string]FilterFunc{
+	"upper":     filterUpper,
+	"lower":     filterLower,
+	"title":     filterTitle,
+	"trim":      filterTrim,
+	"default":   filterDefault,
+	"date":      filterDate,
+	"number":    filterNumber,
+	"json":      filterJson,
+	"truncate":  filterTruncate,
+	"join":      filterJoin,
+	"urlencode": filterUrlencode,
+}
+
+// RegisterFilter register a filter, replacing the default filter with the same name
+func (s *TemplateSystem) RegisterFilter(name string, filter FilterFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Filters == nil {
+		s.Filters = map[string]FilterFunc{}
+	}
+	s.Filters[name] = filter
+	s.expressions = nil
+}
+
+// Filter gets the filter by name, looking first at the filters registered in the system
+func (s *TemplateSystem) Filter(name string) FilterFunc {
+	if s != nil {
+		s.mu.RLock()
+		filter, exists := s.Filters[name]
+		s.mu.RUnlock()
+		if exists {
+			return filter
+		}
+	}
+	return DefaultFilters[name]
+}
+
+// filterString the value as string, nil is empty
+func filterString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, isString := value.(string); isString {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// filterArg the argument at index, or defaultValue when not informed
+func filterArg(args []interface{}, index int, defaultValue interface{}) interface{} {
+	if index < len(args) && args[index] != nil {
+		return args[index]
+	}
+	return defaultValue
+}
+
+// filterFloat converts numbers and numeric strings
+func filterFloat(value interface{}) (float64, bool) {
+	if s, isString := value.(string); isString {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func filterInt(value interface{}, name string) (int, error) {
+	f, isNumber := filterFloat(value)
+	if !isNumber || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%s: expected an integer, received %v", name, value)
+	}
+	return int(f), nil
+}
+
+func filterUpper(value interface{}, args ...interface{}) (interface{}, error) {
+	return strings.ToUpper(filterString(value)), nil
+}
+
+func filterLower(value interface{}, args ...interface{}) (interface{}, error) {
+	return strings.ToLower(filterString(value)), nil
+}
+
+// filterTitle capitalizes the first letter of each word
+func filterTitle(value interface{}, args ...interface{}) (interface{}, error) {
+	runes := []rune(filterString(value))
+	prev := ' '
+	for i, r := range runes {
+		if unicode.IsSpace(prev) || prev == '-' || prev == '_' {
+			runes[i] = unicode.ToTitle(r)
+		}
+		prev = r
+	}
+	return string(runes), nil
+}
+
+func filterTrim(value interface{}, args ...interface{}) (interface{}, error) {
+	return strings.TrimSpace(filterString(value)), nil
+}
+
+// filterDefault { name | default('Anonymous') } used when the value is nil, false or empty
+func filterDefault(value interface{}, args ...interface{}) (interface{}, error) {
+	if value == nil || value == false || value == "" {
+		return filterArg(args, 0, ""), nil
+	}
+	return value, nil
+}
+
+// filterDate { createdAt | date('02/01/2006') } accepts time.Time, RFC3339 strings and unix timestamps (seconds)
+func filterDate(value interface{}, args ...interface{}) (interface{}, error) {
+	layout := filterString(filterArg(args, 0, "2006-01-02"))
+
+	var t time.Time
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		t = *v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("date: %s", err.Error())
+		}
+		t = parsed
+	default:
+		seconds, isNumber := filterFloat(value)
+		if !isNumber {
+			return nil, fmt.Errorf("date: unsupported value %T", value)
+		}
+		t = time.Unix(int64(seconds), 0)
+	}
+	return t.Format(layout), nil
+}
+
+// filterNumber { price | number(2) } formats the number with the given decimals
+func filterNumber(value interface{}, args ...interface{}) (interface{}, error) {
+	if value == nil {
+		return "", nil
+	}
+	f, isNumber := filterFloat(value)
+	if !isNumber {
+		return nil, fmt.Errorf("number: expected a number, received %v", value)
+	}
+	decimals := -1
+	if len(args) > 0 {
+		var err error
+		if decimals, err = filterInt(args[0], "number"); err != nil {
+			return nil, err
+		}
+	}
+	return strconv.FormatFloat(f, 'f', decimals, 64), nil
+}
+
+func filterJson(value interface{}, args ...interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("json: %s", err.Error())
+	}
+	return string(content), nil
+}
+
+// filterTruncate { description | truncate(20) } or { description | truncate(20, '…') }, counts runes
+func filterTruncate(value interface{}, args ...interface{}) (interface{}, error) {
+	text := filterString(value)
+	size, err := filterInt(filterArg(args, 0, nil), "truncate")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("truncate: expected a positive size, received %d", size)
+	}
+	if utf8.RuneCountInString(text) <= size {
+		return text, nil
+	}
+	return string([]rune(text)[:size]) + filterString(filterArg(args, 1, "...")), nil
+}
+
+// filterJoin { tags | join(', ') }
+func filterJoin(value interface{}, args ...interface{}) (interface{}, error) {
+	separator := filterString(filterArg(args, 0, ", "))
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return filterString(value), nil
+	}
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = filterString(v.Index(i).Interface())
+	}
+	return strings.Join(items, separator), nil
+}
+
+func filterUrlencode(value interface{}, args ...interface{}) (interface{}, error) {
+	return url.QueryEscape(filterString(value)), nil
+}
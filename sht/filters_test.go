@@ -0,0 +1,88 @@
+package sht
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Filters(t *testing.T) {
+	template := `
+    <div title="{ name | upper }">
+      <span>{ name | title | truncate(8, '…') }</span>
+      <span>[{ padded | trim }]</span>
+      <span>{ nickname | default('Anonymous') | lower }</span>
+      <span>{ price | number(2) } { tags | join(', ') } { tags | json }</span>
+      <a href="/search?q={ query | urlencode }">{ createdAt | date('02/01/2006') }</a>
+      <span>{ enabled || name == 'x' | upper }</span>
+    </div>`
+
+	expected := `
+    <div title="JOHN DOE-SMITH">
+      <span>John Doe…</span>
+      <span>[text]</span>
+      <span>anonymous</span>
+      <span>10.50 a, b [&#34;a&#34;,&#34;b&#34;]</span>
+      <a href="/search?q=a+%26+b">25/12/2022</a>
+      <span>TRUE</span>
+    </div>`
+
+	values := map[string]interface{}{
+		"name":      "john doe-smith",
+		"padded":    "  text ",
+		"nickname":  "",
+		"price":     10.5,
+		"tags":      []string{"a", "b"},
+		"query":     "a & b",
+		"createdAt": time.Date(2022, 12, 25, 10, 0, 0, 0, time.UTC),
+		"enabled":   true,
+	}
+
+	compiled, _ := TestCompile(t, template, nil, &Directives{})
+	TestRender(t, compiled, values, expected)
+	testEncodeDecode(t, compiled, &Directives{}, values, expected)
+}
+
+func Test_Filters_System(t *testing.T) {
+	ts := &TemplateSystem{Directives: (&Directives{}).NewChild()}
+	ts.RegisterFilter("reverse", func(value interface{}, args ...interface{}) (interface{}, error) {
+		runes := []rune(filterString(value))
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	})
+
+	compiled, err := NewCompiler(ts).Compile(`<span>{ name | reverse | upper }</span>`, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	TestRender(t, compiled, map[string]interface{}{"name": "abc"}, `<span>CBA</span>`)
+
+	// unknown filters are compile errors
+	for _, template := range []string{
+		`<span>{ name | unknown }</span>`,
+		`<span title="{ name | unknown(2) }"></span>`,
+	} {
+		_, err = NewCompiler(&TemplateSystem{Directives: (&Directives{}).NewChild()}).Compile(template, "template.html")
+		if err == nil || !strings.Contains(err.Error(), "[expression.filter.unknown]") {
+			t.Errorf("compiler.Compile(%s) | expect to receive unknown filter error\n   actual: %v", template, err)
+		}
+	}
+
+	if _, err = ParseExpression(`name | 2x`); err == nil || !strings.HasPrefix(err.Error(), "[expression.filter.syntax]") {
+		t.Errorf("ParseExpression() | expect to receive filter syntax error\n   actual: %v", err)
+	}
+}
+
+func Test_Filters_Truncate_Invalid_Size(t *testing.T) {
+	expression, err := ParseExpression(`name | truncate(-1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := (&TemplateSystem{}).NewScope()
+	scope.Set("name", "John")
+	if _, err = expression.run(scope); err == nil || !strings.Contains(err.Error(), "truncate: expected a positive size") {
+		t.Errorf("expression.run(scope) | expect to receive truncate size error\n   actual: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package sht
+
+import (
+	"errors"
+	"github.com/syntax-framework/shtml/cmn"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+var errorPathTraversal = cmn.Err(
+	"path.traversal",
+	"The path resolves outside of the root of the templates.", "Path: %s", "File: %s",
+)
+
+// ResolvePath resolves a path referenced by a file (Ex. href="header.html", src="app.js") relative to the directory of
+// the file. Paths outside the root of the templates are refused (Ex. href="../../etc/passwd").
+func ResolvePath(file string, ref string) (string, error) {
+	resolved := path.Join(path.Dir(file), ref)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", errorPathTraversal(ref, file)
+	}
+	return resolved, nil
+}
+
+// LoaderFS a TemplateSystem.Loader that reads the files of the fsys (Ex. embed.FS, os.DirFS). When the system has an
+// FS and no Loader, the files are read from the FS.
+func LoaderFS(fsys fs.FS) func(filepath string) (string, error) {
+	return func(filepath string) (string, error) {
+		if !fs.ValidPath(filepath) {
+			return "", &fs.PathError{Op: "open", Path: filepath, Err: fs.ErrInvalid}
+		}
+		content, err := fs.ReadFile(fsys, filepath)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+}
+
+// OverlayFS layers the roots, each file is read from the first root that has it, allowing the templates of the
+// application to override the ones of a shared component library. The directories list the files of all the roots.
+//
+// Ex. sht.OverlayFS(os.DirFS("templates"), components.FS)
+func OverlayFS(roots ...fs.FS) fs.FS {
+	return overlayFS(roots)
+}
+
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, root := range o {
+		file, err := root.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir the entries of the directory in all roots, sorted by name
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	found := false
+	byName := map[string]fs.DirEntry{}
+	for _, root := range o {
+		entries, err := fs.ReadDir(root, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, entry := range entries {
+			if _, exists := byName[entry.Name()]; !exists {
+				byName[entry.Name()] = entry
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	var entries []fs.DirEntry
+	for _, entry := range byName {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
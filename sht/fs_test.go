@@ -0,0 +1,69 @@
+package sht
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_ResolvePath(t *testing.T) {
+	tests := []struct {
+		file     string
+		ref      string
+		expected string
+	}{
+		{"template.html", "header.html", "header.html"},
+		{"pages/home.html", "partials/menu.html", "pages/partials/menu.html"},
+		{"pages/home.html", "../app.js", "app.js"},
+		{"pages/home.html", "./a/../b.html", "pages/b.html"},
+	}
+	for _, tt := range tests {
+		if actual, err := ResolvePath(tt.file, tt.ref); err != nil || actual != tt.expected {
+			t.Errorf("ResolvePath(%s, %s) | invalid output\n   actual: %s %v\n expected: %s", tt.file, tt.ref, actual, err, tt.expected)
+		}
+	}
+
+	for _, ref := range []string{"../secret.html", "a/../../secret.html", ".."} {
+		if _, err := ResolvePath("template.html", ref); err == nil || !strings.HasPrefix(err.Error(), "[path.traversal]") {
+			t.Errorf("ResolvePath(template.html, %s) | expect to receive [path.traversal] error\n   actual: %v", ref, err)
+		}
+	}
+}
+
+func Test_OverlayFS(t *testing.T) {
+	app := fstest.MapFS{
+		"pages/home.html": {Data: []byte(`home`)},
+		"card.html":       {Data: []byte(`app card`)},
+	}
+	lib := fstest.MapFS{
+		"card.html":   {Data: []byte(`lib card`)},
+		"button.html": {Data: []byte(`lib button`)},
+	}
+
+	ts := &TemplateSystem{FS: OverlayFS(app, lib)}
+	for file, expected := range map[string]string{"card.html": "app card", "button.html": "lib button", "pages/home.html": "home"} {
+		if actual, err := ts.Load(file); err != nil || actual != expected {
+			t.Errorf("ts.Load(%s) | invalid output\n   actual: %s %v\n expected: %s", file, actual, err, expected)
+		}
+	}
+
+	if _, err := ts.Load("../card.html"); err == nil {
+		t.Errorf("ts.Load(../card.html) | expect to receive error")
+	}
+	if _, err := ts.Load("not-found.html"); err == nil {
+		t.Errorf("ts.Load(not-found.html) | expect to receive error")
+	}
+
+	var files []string
+	_ = fs.WalkDir(ts.FS, ".", func(filepath string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, filepath)
+		}
+		return err
+	})
+	if expected := []string{"button.html", "card.html", "pages/home.html"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("fs.WalkDir(OverlayFS) | invalid files\n   actual: %v\n expected: %v", files, expected)
+	}
+}
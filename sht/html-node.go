@@ -127,6 +127,26 @@ func (n *Node) ExtractChildren() *Node {
 	return newParent
 }
 
+// Clone returns a deep copy of the node and its children, detached from the tree
+func (n *Node) Clone() *Node {
+	clone := &Node{
+		Type:      n.Type,
+		Data:      n.Data,
+		DataAtom:  n.DataAtom,
+		Namespace: n.Namespace,
+		File:      n.File,
+		Line:      n.Line,
+		Column:    n.Column,
+	}
+	if n.Attributes != nil {
+		clone.Attributes = n.Attributes.Clone()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(child.Clone())
+	}
+	return clone
+}
+
 // ReplaceByText used for element transclude, replace current element with plain text, extracting all node data to a
 // separate element
 func (n *Node) ReplaceByText() *Node {
@@ -146,7 +166,7 @@ func (n *Node) ReplaceByText() *Node {
 	n.Data = " "
 	n.DataAtom = atom.Plaintext
 	n.Namespace = " "
-	n.Attributes = &Attributes{Map: map[string]*Attribute{}}
+	n.Attributes = NewAttributes()
 	//n.AttrList = []*Attribute{}
 
 	return newNode
@@ -191,8 +211,8 @@ func (n *Node) DebugTag() string {
 		// Render the <xxx> opening tag.
 		w.WriteByte('<')
 		w.WriteString(n.Data)
-		if n.Attributes != nil && n.Attributes.Map != nil {
-			for _, a := range n.Attributes.Map {
+		if n.Attributes != nil {
+			for _, a := range n.Attributes.List() {
 				w.WriteByte(' ')
 				if a.Namespace != "" {
 					w.WriteString(a.Namespace)
@@ -254,8 +274,8 @@ func (n *Node) toXhtml() *html.Node {
 	//	}
 	//}
 
-	if n.Attributes != nil && n.Attributes.Map != nil {
-		for _, attr := range n.Attributes.Map {
+	if n.Attributes != nil {
+		for _, attr := range n.Attributes.List() {
 			attributes = append(attributes, html.Attribute{
 				Key:       attr.Name,
 				Val:       attr.Value,
@@ -1,7 +1,6 @@
 package sht
 
 import (
-	"github.com/erinpentecost/byteline"
 	"github.com/syntax-framework/shtml/cmn"
 	"golang.org/x/net/html"
 	"io"
@@ -24,14 +23,13 @@ var errorParseEndTag = cmn.Err(
 // The input is assumed to be UTF-8 encoded.
 func Parse(template string, filepath string) ([]*Node, error) {
 
-	lineTracker := byteline.NewReader(strings.NewReader(template))
-	tokenizer := html.NewTokenizer(lineTracker)
+	tokenizer := html.NewTokenizer(strings.NewReader(template))
 
 	// Iterate until EOF. Any other error will cause an early return.
 	p := &parser{root: &Node{Type: DocumentNode}}
 
-	prevCol := 0
-	prevLine := 1
+	// position of the current token
+	prevLine, prevCol := 1, 1
 
 	var err error
 	for err != io.EOF {
@@ -42,10 +40,8 @@ func Parse(template string, filepath string) ([]*Node, error) {
 		// Read and parse the transverse token.
 		tokenizer.Next()
 
-		// https://github.com/erinpentecost/vugu/blob/74175693cd5bc1c30bfb603c266c96bf72cb4e3e/vugufmt/formatter.go#L99
-		totalOffset, _ := lineTracker.GetCurrentOffset()
-		tokenOffset := totalOffset - len(tokenizer.Buffered())
-		curLine, curCol, _ := lineTracker.GetLineAndColumn(tokenOffset)
+		// the tokens are contiguous, the next token starts where this one ends
+		nextLine, nextCol := advancePosition(tokenizer.Raw(), prevLine, prevCol)
 
 		token := tokenizer.Token()
 		if token.Type == html.ErrorToken {
@@ -78,8 +74,7 @@ func Parse(template string, filepath string) ([]*Node, error) {
 			p.addChild(createNode(token, filepath, prevLine, prevCol))
 		}
 
-		prevCol = curCol + 1
-		prevLine = curLine + 1
+		prevLine, prevCol = nextLine, nextCol
 	}
 
 	var nodes []*Node
@@ -92,3 +87,16 @@ func Parse(template string, filepath string) ([]*Node, error) {
 
 	return nodes, nil
 }
+
+// advancePosition the line and column after the content (line and column start at 1)
+func advancePosition(content []byte, line int, column int) (int, int) {
+	for _, r := range string(content) {
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
@@ -0,0 +1,47 @@
+package sht
+
+import (
+	"testing"
+)
+
+func Test_Parse_Positions(t *testing.T) {
+	template := "<div>\n  <span class=\"a\"\n        id=\"b\">olá</span><b>x</b>\n<!-- c -->\n  <i>\n</i></div>"
+
+	nodes, err := Parse(template, "template.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		data   string
+		line   int
+		column int
+	}{
+		{"div", 1, 1},
+		{"\n  ", 1, 6},
+		{"span", 2, 3},
+		{"olá", 3, 16},
+		{"b", 3, 26}, // columns are counted in runes
+		{"x", 3, 29},
+		{"\n", 3, 34},
+		{" c ", 4, 1},
+		{"\n  ", 4, 11},
+		{"i", 5, 3},
+		{"\n", 5, 6},
+	}
+
+	var visited []*Node
+	nodes[0].Transverse(func(node *Node) bool {
+		visited = append(visited, node)
+		return false
+	})
+	if len(visited) != len(expected) {
+		t.Fatalf("Parse(template) | invalid number of nodes\n   actual: %d\n expected: %d", len(visited), len(expected))
+	}
+	for i, node := range visited {
+		e := expected[i]
+		if node.Data != e.data || node.Line != e.line || node.Column != e.column || node.File != "template.html" {
+			t.Errorf("Parse(template) | invalid position\n   actual: %q %d:%d\n expected: %q %d:%d", node.Data, node.Line, node.Column, e.data, e.line, e.column)
+		}
+	}
+}
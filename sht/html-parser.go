@@ -63,10 +63,10 @@ func createNode(token html.Token, file string, line int, column int) *Node {
 			attrList[i] = NewAttribute(attr.Key, attr.Val, attr.Namespace)
 		}
 
-		attributes := &Attributes{Map: map[string]*Attribute{}}
+		attributes := NewAttributes()
 		for _, attr := range attrList {
 			if attr.Normalized != "" {
-				attributes.Map[attr.Normalized] = attr
+				attributes.Add(attr)
 			}
 		}
 
@@ -0,0 +1,201 @@
+package sht
+
+import (
+	"github.com/syntax-framework/shtml/cmn"
+	"golang.org/x/net/websocket"
+	"log"
+	"net/http"
+	"sync"
+)
+
+var errorLiveEventUnknown = cmn.Err(
+	"live.event.unknown",
+	"There is no handler for the event.", "Event: %s",
+)
+
+var errorLiveComponentUnknown = cmn.Err(
+	"live.component.unknown",
+	"There is no handler for the events of the component.", "Component: %s", "Event: %s",
+)
+
+var errorLiveInstanceUnknown = cmn.Err(
+	"live.instance.unknown",
+	"The instance of the component was not rendered.", "Component: %s", "Instance: %d", "Event: %s",
+)
+
+var errorLiveMessage = cmn.Err(
+	"live.message",
+	"Invalid message.", "Type: %s",
+)
+
+// LiveEventHandler handles an event pushed by the client (push('increment', count)). The changes made to the scope are
+// sent to the client after the handler returns.
+type LiveEventHandler func(session *LiveSession, payload []interface{}) error
+
+// Live messages exchanged with the client, encoded as JSON
+//
+// client -> server: {"t":"push","e":"increment","p":[1]}
+// client -> server: {"t":"push","c":"my-counter","i":1,"e":"increment","p":[1]} (event of the second instance of the component)
+// server -> client: {"t":"render","d":{...}} (the changes since the last render, see RenderedDiff), {"t":"error","err":"..."}
+type liveMessage struct {
+	Type      string        `json:"t"`
+	Component string        `json:"c,omitempty"`
+	Instance  int           `json:"i,omitempty"` // the order of rendering of the instance of the component, from 0
+	Event     string        `json:"e,omitempty"`
+	Payload   []interface{} `json:"p,omitempty"`
+	Diff      *RenderedDiff `json:"d,omitempty"`
+	Error     string        `json:"err,omitempty"`
+}
+
+// Live serves a Compiled over WebSocket. Each connection has its own LiveSession, whose Scope is kept between the
+// events pushed by the client. After each event the Compiled is executed again and only the changes are sent.
+type Live struct {
+	Compiled *Compiled
+	// OnConnect initializes the scope of the session, the connection is closed when it returns an error
+	OnConnect func(session *LiveSession, r *http.Request) error
+	// OnDisconnect executed after the connection is closed
+	OnDisconnect func(session *LiveSession)
+	handlers     map[string]LiveEventHandler
+	components   map[string]*Component
+}
+
+// Handle registers the handler of an event pushed by the page
+func (l *Live) Handle(event string, handler LiveEventHandler) {
+	if l.handlers == nil {
+		l.handlers = map[string]LiveEventHandler{}
+	}
+	l.handlers[event] = handler
+}
+
+// HandleComponent registers the handlers of the component (see Component.Handle). Each instance of the component keeps
+// its scope during the session, the handlers are invoked with the scope of the instance that pushed the event
+func (l *Live) HandleComponent(component *Component) {
+	if l.components == nil {
+		l.components = map[string]*Component{}
+	}
+	l.components[component.Name] = component
+}
+
+// ServeHTTP upgrades the connection to WebSocket and keeps the session until the client disconnects
+func (l *Live) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(l.serve).ServeHTTP(w, r)
+}
+
+func (l *Live) serve(conn *websocket.Conn) {
+	session := &LiveSession{
+		Scope: NewRootScope(),
+		live:  l,
+		conn:  conn,
+	}
+	session.Scope.instances = map[string][]*Scope{}
+	defer conn.Close()
+
+	if l.OnConnect != nil {
+		if err := l.OnConnect(session, conn.Request()); err != nil {
+			session.sendError(err)
+			return
+		}
+	}
+
+	if l.OnDisconnect != nil {
+		defer l.OnDisconnect(session)
+	}
+
+	if err := session.Render(); err != nil {
+		return
+	}
+
+	for {
+		message := &liveMessage{}
+		if err := websocket.JSON.Receive(conn, message); err != nil {
+			return // disconnected
+		}
+
+		if err := session.dispatch(message); err != nil {
+			if session.sendError(err) != nil {
+				return
+			}
+			continue
+		}
+
+		if err := session.Render(); err != nil {
+			return
+		}
+	}
+}
+
+// LiveSession the state of a client connected to a Live
+type LiveSession struct {
+	Scope    *Scope
+	live     *Live
+	conn     *websocket.Conn
+	mutex    sync.Mutex
+	rendered *Rendered // the last Rendered sent to the client
+}
+
+// dispatch invokes the handler of the event pushed by the client
+func (s *LiveSession) dispatch(message *liveMessage) error {
+	if message.Type != "push" {
+		return errorLiveMessage(message.Type)
+	}
+
+	if message.Component != "" {
+		component, exists := s.live.components[message.Component]
+		if !exists {
+			return errorLiveComponentUnknown(message.Component, message.Event)
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		scope := s.Scope.instance(component.Name, message.Instance)
+		if scope == nil {
+			return errorLiveInstanceUnknown(message.Component, message.Instance, message.Event)
+		}
+		return component.Dispatch(scope, message.Event, message.Payload)
+	}
+
+	handler, exists := s.live.handlers[message.Event]
+	if !exists {
+		return errorLiveEventUnknown(message.Event)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return handler(s, message.Payload)
+}
+
+// Render executes the Compiled and sends the changes to the client. Allows the server to update the client outside of
+// an event (Ex. timers, pub/sub)
+func (s *LiveSession) Render() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// the instances of the components are identified by the order of rendering
+	rendered, renderErr := s.live.Compiled.Execute(s.Scope)
+	s.Scope.discardInstances()
+	diff := Diff(s.rendered, rendered)
+	s.rendered = rendered
+	if diff != nil {
+		if err := websocket.JSON.Send(s.conn, &liveMessage{Type: "render", Diff: diff}); err != nil {
+			return err
+		}
+	}
+	if renderErr != nil {
+		// when strict (see Scope.SetStrict)
+		return s.writeError(renderErr)
+	}
+	return nil
+}
+
+func (s *LiveSession) sendError(err error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.writeError(err)
+}
+
+// writeError sends the error to the client, s.mutex must be locked
+func (s *LiveSession) writeError(err error) error {
+	// @TODO: Log.Warning
+	log.Print(err)
+	return websocket.JSON.Send(s.conn, &liveMessage{Type: "error", Error: err.Error()})
+}
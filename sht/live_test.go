@@ -0,0 +1,146 @@
+package sht
+
+import (
+	"encoding/json"
+	"github.com/syntax-framework/shtml/cmn"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testLiveReceive(t *testing.T, conn *websocket.Conn) string {
+	var message json.RawMessage
+	if err := websocket.JSON.Receive(conn, &message); err != nil {
+		t.Fatal(err)
+	}
+	return string(message)
+}
+
+func Test_Live(t *testing.T) {
+	compiled, _ := TestCompile(t, `<div><span>{title}</span><b>{count}</b></div>`, nil, &Directives{})
+
+	disconnected := make(chan bool, 1)
+	live := &Live{
+		Compiled: compiled,
+		OnConnect: func(session *LiveSession, r *http.Request) error {
+			session.Scope.Set("title", r.URL.Query().Get("title"))
+			session.Scope.Set("count", 0)
+			return nil
+		},
+		OnDisconnect: func(session *LiveSession) {
+			disconnected <- true
+		},
+	}
+	live.Handle("increment", func(session *LiveSession, payload []interface{}) error {
+		count, _ := session.Scope.Get("count")
+		session.Scope.Set("count", count.(int)+int(payload[0].(float64)))
+		return nil
+	})
+
+	server := httptest.NewServer(live)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?title=Counter"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first render, full
+	if actual := testLiveReceive(t, conn); !strings.Contains(actual, `"s":[`) ||
+		!strings.Contains(actual, `"0":"Counter","1":"0"`) {
+		t.Errorf("live | invalid first render\n   actual: %s", actual)
+	}
+
+	if err = websocket.JSON.Send(conn, map[string]interface{}{"t": "push", "e": "increment", "p": []int{2}}); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := testLiveReceive(t, conn), `{"t":"render","d":{"d":{"1":"2"}}}`; actual != expected {
+		t.Errorf("live | invalid diff\n   actual: %s\n expected: %s", actual, expected)
+	}
+
+	if err = websocket.JSON.Send(conn, map[string]interface{}{"t": "push", "e": "unknown"}); err != nil {
+		t.Fatal(err)
+	}
+	if actual := testLiveReceive(t, conn); !strings.Contains(actual, `[live.event.unknown]`) {
+		t.Errorf("live | expect error message\n   actual: %s", actual)
+	}
+
+	conn.Close()
+	<-disconnected
+}
+
+func Test_Live_Component(t *testing.T) {
+	ts := &TemplateSystem{Directives: (&Directives{}).NewChild()}
+	content, err := NewCompiler(ts).Compile(`<b>{count}</b>`, "counter.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &Component{
+		Name:     "my-counter",
+		File:     "counter.html",
+		Compiled: content,
+		Params: []cmn.ComponentParam{
+			{Name: "step", Type: cmn.ParamTypeNumber, TypeName: "number", Required: true},
+			{Name: "count", Type: cmn.ParamTypeNumber, TypeName: "number", Default: "0", HasDefault: true},
+		},
+		Events: map[string]int{"increment": 0},
+	}
+	if err = ts.RegisterComponent(counter); err != nil {
+		t.Fatal(err)
+	}
+	err = counter.Handle("increment", func(scope *Scope) {
+		count, _ := scope.Get("count")
+		step, _ := scope.Get("step")
+		scope.Set("count", count.(float64)+step.(float64))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := NewCompiler(ts).Compile(`<my-counter param-step="1"></my-counter><my-counter param-step="{step}"></my-counter>`, "page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	live := &Live{
+		Compiled: compiled,
+		OnConnect: func(session *LiveSession, r *http.Request) error {
+			session.Scope.Set("step", 10)
+			return nil
+		},
+	}
+	live.HandleComponent(counter)
+
+	server := httptest.NewServer(live)
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if actual := testLiveReceive(t, conn); !strings.Contains(actual, `"0":"0"`) {
+		t.Errorf("live | invalid first render\n   actual: %s", actual)
+	}
+
+	// only the second instance is changed, the state is kept between the events
+	for _, expected := range []string{`"10"`, `"20"`} {
+		if err = websocket.JSON.Send(conn, map[string]interface{}{"t": "push", "c": "my-counter", "i": 1, "e": "increment"}); err != nil {
+			t.Fatal(err)
+		}
+		if actual := testLiveReceive(t, conn); !strings.Contains(actual, `"1":{"d":{"0":`+expected+`}}`) || strings.Contains(actual, `"0":{`) {
+			t.Errorf("live | invalid diff of the instance\n   actual: %s\n expected: %s", actual, expected)
+		}
+	}
+
+	if err = websocket.JSON.Send(conn, map[string]interface{}{"t": "push", "c": "my-counter", "i": 2, "e": "increment"}); err != nil {
+		t.Fatal(err)
+	}
+	if actual := testLiveReceive(t, conn); !strings.Contains(actual, `[live.instance.unknown]`) {
+		t.Errorf("live | expect error message\n   actual: %s", actual)
+	}
+}
@@ -1,6 +1,10 @@
 package sht
 
-import "bytes"
+import (
+	"bytes"
+	"io"
+	"strings"
+)
 
 // Rendered structure of a Compiled
 type Rendered struct {
@@ -8,28 +12,90 @@ type Rendered struct {
 	Dynamics    []interface{} `json:"d"` // nil, string, Rendered
 	Fingerprint string        `json:"f"`
 	Root        bool          `json:"r"`
-	Assets      []string      `json:"a"` // all the resources needed by that part
+	Assets      []string      `json:"a"`           // all the resources needed by that part
+	Keys        []string      `json:"k,omitempty"` // when list (Static == nil), the key of each item
 }
 
 // Write the output to the given buffer
 func (r *Rendered) Write(buffer *bytes.Buffer) {
+	_, _ = r.WriteTo(buffer)
+}
+
+// WriteTo writes the output to w, implements io.WriterTo
+func (r *Rendered) WriteTo(w io.Writer) (int64, error) {
+	out := &renderWriter{w: w}
+	out.writeRendered(r)
+	return out.n, out.err
+}
+
+// renderWriter writes the output of the rendering, after the first error nothing else is written
+type renderWriter struct {
+	w          io.Writer
+	flushAfter []string // flushes w after writing a static part that contains one of these (Ex. "</head>")
+	n          int64
+	err        error
+}
+
+func (o *renderWriter) writeString(text string) {
+	if o.err != nil || text == "" {
+		return
+	}
+	n, err := io.WriteString(o.w, text)
+	o.n += int64(n)
+	o.err = err
+}
+
+// writeStatic writes a static part, flushing right after the last flush point it contains
+func (o *renderWriter) writeStatic(text string) {
+	end := -1
+	for _, point := range o.flushAfter {
+		if index := strings.LastIndex(text, point); index >= 0 && index+len(point) > end {
+			end = index + len(point)
+		}
+	}
+	if end < 0 {
+		o.writeString(text)
+		return
+	}
+	o.writeString(text[:end])
+	if o.err == nil {
+		o.flush()
+	}
+	o.writeString(text[end:])
+}
+
+// flush supports http.Flusher and writers like *bufio.Writer
+func (o *renderWriter) flush() {
+	switch f := o.w.(type) {
+	case interface{ Flush() error }:
+		o.err = f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+}
+
+// writeDynamic nil, string, *Rendered
+func (o *renderWriter) writeDynamic(dynamic interface{}) {
+	if value, ok := dynamic.(string); ok {
+		o.writeString(value)
+	} else if rendered, ok := dynamic.(*Rendered); ok && rendered != nil {
+		o.writeRendered(rendered)
+	}
+}
+
+func (o *renderWriter) writeRendered(r *Rendered) {
 	if r.Static != nil {
 		static := *r.Static
-		for i := 0; i < len(static); i++ {
-			if i == 0 {
-				buffer.WriteString(static[i])
-			} else {
-				// nil, string, Rendered
-				dynamic := r.Dynamics[i-1]
-				if dynamic != nil {
-					if value, ok := dynamic.(string); ok {
-						buffer.WriteString(value)
-					} else if rendered, ok := dynamic.(*Rendered); ok && rendered != nil {
-						rendered.Write(buffer)
-					}
-				}
-				buffer.WriteString(static[i])
+		for i := 0; i < len(static) && o.err == nil; i++ {
+			if i > 0 {
+				o.writeDynamic(r.Dynamics[i-1])
 			}
+			o.writeStatic(static[i])
+		}
+	} else {
+		// list, each dynamic is an item (Ex. <for>)
+		for _, dynamic := range r.Dynamics {
+			o.writeDynamic(dynamic)
 		}
 	}
 }
@@ -1,5 +1,7 @@
 package sht
 
+import "log"
+
 type Scope struct {
 	Context   *Context // allows directives to save context information during execution
 	root      *Scope
@@ -7,6 +9,14 @@ type Scope struct {
 	destroyed bool
 	children  map[*Scope]bool
 	data      map[string]interface{}
+	// transclude when rendering the template of a directive, gives access to the original content of the element
+	transclude TranscludeFunc
+	strict     bool    // root only, see SetStrict
+	errors     []error // root only, the errors collected when strict
+	// root only, the scopes of the instances of the components kept between the renders (see LiveSession), by
+	// component name and order of rendering
+	instances      map[string][]*Scope
+	instanceCounts map[string]int // root only, the instances of each component rendered so far
 }
 
 func NewRootScope() *Scope {
@@ -62,6 +72,112 @@ func (s *Scope) Set(key string, value interface{}) {
 	target.data[key] = value
 }
 
+// SetLocal set a value in this scope, without looking for the key in the parent scopes
+func (s *Scope) SetLocal(key string, value interface{}) {
+	s.data[key] = value
+}
+
+// SetStrict when strict, undefined variables and errors of the expressions are collected as RenderError instead of
+// being logged (see Compiled.Execute). Applies to the whole tree of scopes. The undefined variables of an expression
+// with the default filter are not reported ({ nickname | default('Anonymous') }).
+func (s *Scope) SetStrict(strict bool) {
+	s.root.strict = strict
+}
+
+// Strict see SetStrict
+func (s *Scope) Strict() bool {
+	return s != nil && s.root.strict
+}
+
+// Errors the errors collected while rendering with this scope, when strict (see SetStrict). The errors returned by
+// Compiled.Execute and Compiled.Render are removed from the scope, so a scope reused between renders (Ex. LiveSession)
+// does not accumulate them
+func (s *Scope) Errors() []error {
+	if s == nil {
+		return nil
+	}
+	return s.root.errors
+}
+
+// takeErrors removes and returns the errors collected since start (see Errors)
+func (s *Scope) takeErrors(start int) []error {
+	if s == nil || len(s.root.errors) <= start {
+		return nil
+	}
+	errs := append([]error(nil), s.root.errors[start:]...)
+	if start == 0 {
+		s.root.errors = nil
+	} else {
+		s.root.errors = s.root.errors[:start]
+	}
+	return errs
+}
+
+func (s *Scope) addError(err error) {
+	s.root.errors = append(s.root.errors, err)
+}
+
+// ReportError reports an error of the rendering (Ex. invalid data), collected when strict (see SetStrict) otherwise
+// logged
+func (s *Scope) ReportError(err error) {
+	if s.Strict() {
+		s.addError(err)
+	} else {
+		// @TODO: Log.Warning
+		log.Print(err)
+	}
+}
+
+// instanceScope the scope of the next instance of the component being rendered, created is false when the scope of
+// the instance was kept from a previous render
+func (s *Scope) instanceScope(component string) (scope *Scope, created bool) {
+	root := s.root
+	if root.instances == nil {
+		return s.New(true), true
+	}
+	if root.instanceCounts == nil {
+		root.instanceCounts = map[string]int{}
+	}
+	instance := root.instanceCounts[component]
+	root.instanceCounts[component]++
+
+	if instances := root.instances[component]; instance < len(instances) {
+		return instances[instance], false
+	}
+	scope = s.New(true)
+	root.instances[component] = append(root.instances[component], scope)
+	return scope, true
+}
+
+// instance the scope of an instance of the component kept by the root, nil when it was not rendered
+func (s *Scope) instance(component string, instance int) *Scope {
+	if instances := s.root.instances[component]; instance >= 0 && instance < len(instances) {
+		return instances[instance]
+	}
+	return nil
+}
+
+// discardInstances removes the scopes of the instances that were not rendered since the last call
+func (s *Scope) discardInstances() {
+	root := s.root
+	for component, instances := range root.instances {
+		if count := root.instanceCounts[component]; count < len(instances) {
+			root.instances[component] = instances[:count]
+		}
+	}
+	root.instanceCounts = map[string]int{}
+}
+
+// Transclude the transclude function of the nearest directive template being rendered, nil when there is none
+func (s *Scope) Transclude() TranscludeFunc {
+	for target := s; target != nil; target = target.parent {
+		if target.transclude != nil {
+			return target.transclude
+		}
+	}
+	return nil
+}
+
 func (s *Scope) Destroy() {
 	// We can't destroy a scope that has been already destroyed.
 	if s.destroyed {
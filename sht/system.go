@@ -2,15 +2,33 @@ package sht
 
 import (
 	"github.com/syntax-framework/shtml/cmn"
+	"io/fs"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
+	"sync"
 )
 
+// TemplateSystem compiles and caches the templates. It is safe for concurrent use, the configuration (Dev, Loader,
+// Stat, FS, Directives) must be done before the first compilation.
+//
+// The files are read by the Loader or, when not informed, from the FS (see LoaderFS and OverlayFS)
 type TemplateSystem struct {
-	Loader     func(filepath string) (string, error)
-	Directives *Directives
-	Assets     map[*cmn.Asset]bool // All Assets that referenced in this system
+	Strict      bool // the scopes created by NewScope are strict, see Scope.SetStrict
+	Dev         bool // recompiles the cached templates whose files were modified, requires Stat or FS, see Compile
+	Loader      func(filepath string) (string, error)
+	Stat        func(filepath string) (fs.FileInfo, error) // used by the dev mode to check the modification of files, see StatFS
+	FS          fs.FS                                      // the templates, used by CompileAll and when there is no Loader or Stat
+	Directives  *Directives
+	Assets      map[*cmn.Asset]bool    // All Assets that referenced in this system
+	Components  map[string]*Component  // All components declared in the templates of this system
+	Filters     map[string]FilterFunc  // The filters registered in this system, in addition to the DefaultFilters
+	env         expressionEnv          // The functions and constants of the expressions (see Funcs and Consts)
+	expressions map[string]*Expression // The expressions compiled in this system, by source
+	cache       map[string]*cacheEntry // The templates compiled by Compile, by path
+	compiling   map[string]*compileCall
+	mu          sync.RWMutex
 }
 
 // Register a global directive
@@ -20,21 +38,45 @@ func (s *TemplateSystem) Register(directives ...*Directive) {
 	}
 }
 
-// Load load an html file
+// Load load an html file, from the FS when the system has no Loader
 func (s *TemplateSystem) Load(filepath string) (string, error) {
+	if s.Loader == nil && s.FS != nil {
+		return LoaderFS(s.FS)(filepath)
+	}
 	return s.Loader(filepath)
 }
 
-func (s *TemplateSystem) Compile(filepath string) (*Compiled, *Context, error) {
+// CompileWithEnv compiles the file checking all the expressions ({...}, cond="", attribute interpolations) against the
+// env, which is a struct or map with the values that will be available on the scope when rendering.
+//
+// Variables that are not in the env (Ex. {user.nmae}) are reported as compile errors, all errors of the file are
+// returned at once (cmn.ErrorList). The functions and constants of the system are also available (see Funcs).
+//
+// The result is not cached, see Compile.
+//
+// Ex. system.CompileWithEnv("profile.html", ProfilePage{})
+func (s *TemplateSystem) CompileWithEnv(filepath string, env interface{}) (*Compiled, *Context, error) {
+	if env == nil {
+		env = map[string]interface{}{}
+	}
+	compiler, compiled, err := s.compile(filepath, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return compiled, compiler.Context, nil
+}
+
+func (s *TemplateSystem) compile(filepath string, env interface{}) (*Compiler, *Compiled, error) {
+
+	compiler := NewCompiler(s)
+	compiler.env = env
 
 	var err error
 	var content string
-	if content, err = s.Load(filepath); err != nil {
+	if content, err = compiler.Load(filepath); err != nil {
 		return nil, nil, err
 	}
 
-	compiler := NewCompiler(s)
-
 	var compiled *Compiled
 	if compiled, err = compiler.Compile(content, filepath); err != nil {
 		return nil, nil, err
@@ -44,19 +86,32 @@ func (s *TemplateSystem) Compile(filepath string) (*Compiled, *Context, error) {
 	for asset, _ := range compiler.Assets {
 		assets = append(assets, asset)
 	}
+	// stable order, allows to encode the Compiled
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Name < assets[j].Name
+	})
 
 	compiled.Assets = assets
 
-	return compiled, compiler.Context, err
+	return compiler, compiled, nil
 }
 
 // NewScope creates a new scope that can be used to render a compiled
 func (s *TemplateSystem) NewScope() *Scope {
-	return NewRootScope()
+	scope := NewRootScope()
+	scope.SetStrict(s.Strict)
+	return scope
 }
 
 // RegisterAsset register an asset
 func (s *TemplateSystem) RegisterAsset(asset *cmn.Asset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registerAsset(asset)
+}
+
+// registerAsset s.mu must be locked
+func (s *TemplateSystem) registerAsset(asset *cmn.Asset) {
 	if s.Assets == nil {
 		s.Assets = map[*cmn.Asset]bool{}
 	}
@@ -118,11 +173,11 @@ func (s *TemplateSystem) RegisterAssetJsURL(src string) (*cmn.Asset, error) {
 // RegisterAssetJsFilepath registers an existing javascript on the filesystem being used by this system
 func (s *TemplateSystem) RegisterAssetJsFilepath(filepath string) (*cmn.Asset, error) {
 
-	// check if is loaded
-	for asset, _ := range s.Assets {
-		if asset.Filepath == filepath {
-			return asset, nil
-		}
+	s.mu.RLock()
+	asset := s.assetByFilepath(filepath)
+	s.mu.RUnlock()
+	if asset != nil && !s.Dev {
+		return asset, nil
 	}
 
 	content, err := s.Load(filepath)
@@ -130,19 +185,42 @@ func (s *TemplateSystem) RegisterAssetJsFilepath(filepath string) (*cmn.Asset, e
 		return nil, err
 	}
 
-	cbytes := []byte(content)
-	asset := &cmn.Asset{
-		Content:  cbytes,
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// loaded concurrently
+	previous := s.assetByFilepath(filepath)
+	if previous != nil && content == string(previous.Content) {
+		return previous, nil
+	}
+
+	// in dev mode, the file was modified. The previous asset is still used by the templates compiled before, a new one
+	// replaces it
+	if previous != nil {
+		delete(s.Assets, previous)
+	}
+	asset = &cmn.Asset{
+		Content:  []byte(content),
 		Name:     path.Base(filepath),
 		Type:     cmn.Javascript,
 		Filepath: filepath,
 	}
 
-	s.RegisterAsset(asset)
+	s.registerAsset(asset)
 
 	return asset, nil
 }
 
+// assetByFilepath s.mu must be locked
+func (s *TemplateSystem) assetByFilepath(filepath string) *cmn.Asset {
+	for asset, _ := range s.Assets {
+		if asset.Filepath == filepath {
+			return asset
+		}
+	}
+	return nil
+}
+
 // RegisterAssetJsContent register an anonymous javascript
 func (s *TemplateSystem) RegisterAssetJsContent(content string) *cmn.Asset {
 	cbytes := []byte(content)
@@ -2,6 +2,7 @@ package sht
 
 import (
 	"bytes"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -46,6 +47,20 @@ func TestRender(t *testing.T, compiled *Compiled, values map[string]interface{},
 	if actual := out.String(); actual != expected {
 		t.Errorf("compiled.Write(*bytes.Buffer) | invalid output\n   actual: %q\n expected: %q", actual, expected)
 	}
+
+	// the streaming rendering must produce the same output
+	streamScope := NewRootScope()
+	for key, value := range values {
+		if reflect.ValueOf(value).Kind() == reflect.Chan {
+			return rendered, scope // consumed by the first rendering
+		}
+		streamScope.Set(key, value)
+	}
+	streamed := &bytes.Buffer{}
+	_ = compiled.Render(streamed, streamScope)
+	if actual := streamed.String(); actual != expected {
+		t.Errorf("compiled.Render(*bytes.Buffer, scope) | invalid output\n   actual: %q\n expected: %q", actual, expected)
+	}
 	return rendered, scope
 }
 
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type StringSet map[string]bool
@@ -34,13 +35,13 @@ func (p StringSet) Clone(others ...string) StringSet {
 type Sequence struct {
 	Salt string
 	seq  int
+	mu   sync.Mutex
 }
 
 const numbers = "0123456789"
 
 func (s *Sequence) NextHash() string {
-	s.seq++
-	hash := HashXXH64([]byte(s.Salt + strconv.Itoa(s.seq)))
+	hash := HashXXH64([]byte(s.Salt + strconv.Itoa(s.NextInt())))
 	if strings.Contains(numbers, hash[:1]) {
 		// add _ if hash starts with number
 		return "_" + hash
@@ -49,6 +50,8 @@ func (s *Sequence) NextHash() string {
 }
 
 func (s *Sequence) NextInt() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.seq++
 	return s.seq
 }
@@ -3,12 +3,14 @@ package shtml
 import (
 	"github.com/syntax-framework/shtml/directives"
 	"github.com/syntax-framework/shtml/sht"
+	"io/fs"
 )
 
 // TemplateSystem interface for configuration, loading and compilation of templates
 type TemplateSystem interface {
 	Load(filepath string) (string, error)
 	Compile(filepath string) (*sht.Compiled, *sht.Context, error)
+	CompileAll(patterns ...string) (map[string]*sht.Compiled, error)
 	NewScope() *sht.Scope
 	Register(directives ...*sht.Directive)
 }
@@ -28,8 +30,39 @@ func New(loader func(filepath string) (string, error)) TemplateSystem {
 	}
 }
 
+// NewFS create a new TemplateSystem that reads the templates from the fsys (Ex. embed.FS, os.DirFS)
+func NewFS(fsys fs.FS) TemplateSystem {
+	return &sht.TemplateSystem{
+		FS:         fsys,
+		Directives: globalDirectives.NewChild(),
+	}
+}
+
+// NewOverlay create a new TemplateSystem that reads the templates from the first root that has the file, see
+// sht.OverlayFS
+//
+// Ex. shtml.NewOverlay(os.DirFS("templates"), components.FS)
+func NewOverlay(roots ...fs.FS) TemplateSystem {
+	return NewFS(sht.OverlayFS(roots...))
+}
+
 func init() {
 	Register(directives.IFElement)
 	Register(directives.IFAttribute)
+	Register(directives.ElseIfElement)
+	Register(directives.ElseElement)
+	Register(directives.ElseIfAttribute)
+	Register(directives.ElseAttribute)
+	Register(directives.ForElement)
+	Register(directives.ForAttribute)
+	Register(directives.SwitchElement)
+	Register(directives.CaseElement)
+	Register(directives.DefaultElement)
+	Register(directives.Component)
+	Register(directives.TranscludeElement)
 	Register(directives.Script)
+	Register(directives.LinkDirective)
+	Register(directives.ExtendsElement)
+	Register(directives.BlockElement)
+	Register(directives.BlockParentElement)
 }
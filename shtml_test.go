@@ -0,0 +1,28 @@
+package shtml
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_Component(t *testing.T) {
+	ts := NewFS(fstest.MapFS{
+		"page.html": {Data: []byte(`<component name="my-card" param-title="string"><b>{title}</b></component><my-card param-title="{name}"></my-card>`)},
+	})
+
+	compiled, _, err := ts.Compile("page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := ts.NewScope()
+	scope.Set("name", "Card")
+	rendered, err := compiled.Execute(scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := strings.TrimSpace(rendered.String()), "<b>Card</b>"; actual != expected {
+		t.Errorf("ts.Compile(filepath) | invalid output\n   actual: %s\n expected: %s", actual, expected)
+	}
+}